@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// backtest replays kline csv files with the config, eg:
+// bot -c config.yaml backtest -balance 1000 DOGEUSDT-1m-2021-05.csv ETHUSDT-1m-2021-05.csv
+func backtest(args []string) {
+	var bt trade.BacktestOption
+	fs := flag.NewFlagSet("backtest", flag.ExitOnError)
+	fs.Float64Var(&bt.Balance, "balance", 1000, "initial balance of main coin")
+	fs.Float64Var(&bt.Fee, "fee", 0.001, "commission rate of every fill")
+	fs.Float64Var(&bt.Slippage, "slippage", 0.0005, "rate market orders are filled worse than the close price")
+	verbose := fs.Bool("v", false, "print every trade")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		log.Fatal("usage: bot backtest [flags] kline.csv...")
+	}
+
+	var klines []*trade.Kline
+	for _, file := range fs.Args() {
+		k, err := trade.LoadKlineFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		klines = append(klines, k...)
+	}
+
+	opt := loadOption(config)
+	report, err := trade.Backtest(context.Background(), *opt, bt, klines)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if *verbose {
+		fmt.Fprintln(w, "SYMBOL\tBUY TIME\tSELL TIME\tBUY\tSELL\tQUANTITY\tFEE\tPNL\tREASON")
+		for _, t := range report.Trades {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%.8g\t%.8g\t%.8g\t%.4f\t%.4f\t%s\n",
+				t.Symbol, t.BuyTime.Format(time.RFC3339), t.SellTime.Format(time.RFC3339),
+				t.BuyPrice, t.SellPrice, t.Quantity, t.Fee, t.PnL, t.Reason)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "trades\t%d\n", len(report.Trades))
+	fmt.Fprintf(w, "open positions\t%d\n", len(report.Open))
+	fmt.Fprintf(w, "win rate\t%.2f%%\n", report.WinRate)
+	fmt.Fprintf(w, "total pnl\t%.4f %s\n", report.TotalPnL, opt.BuyOption.MainCoin)
+	fmt.Fprintf(w, "fees\t%.4f %s\n", report.Fees, opt.BuyOption.MainCoin)
+	fmt.Fprintf(w, "max drawdown\t%.4f %s (%.2f%%)\n", report.MaxDrawdown, opt.BuyOption.MainCoin, report.MaxDrawdownPercent)
+	fmt.Fprintf(w, "equity\t%.4f -> %.4f %s\n", report.StartBalance, report.EndEquity, opt.BuyOption.MainCoin)
+	w.Flush()
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
-	"bytes"
 	"flag"
 	"github.com/adshao/go-binance/v2"
+	"github.com/clearcodecn/binance-bot/pkg/manager"
 	"github.com/clearcodecn/binance-bot/pkg/trade"
 	"github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/signal"
@@ -16,56 +14,77 @@ import (
 
 var (
 	config string
+	bots   string
+	bot    string
+	addr   string
+	token  string
 )
 
 func init() {
 	flag.StringVar(&config, "c", "config.yaml", "config file")
+	flag.StringVar(&bots, "bots", "", "bots file running several bots with their own config files, -c is ignored if set")
+	flag.StringVar(&bot, "bot", "", "name of the bot in the bots file control commands are sent to, default: the first bot")
+	flag.StringVar(&addr, "addr", "http://127.0.0.1:8080", "http server of the running bot, used by control commands")
+	flag.StringVar(&token, "token", "", "api token of the running bot, default: SystemOption.APIToken in config")
 }
 
-func main() {
-	flag.Parse()
-
-	data, err := ioutil.ReadFile(config)
+// loadManager loads the bots of -bots, or the bot of -c.
+func loadManager() *manager.Manager {
+	var (
+		m   *manager.Manager
+		err error
+	)
+	if bots != "" {
+		var config *manager.Config
+		if config, err = manager.LoadConfig(bots); err == nil {
+			m, err = manager.Load(config)
+		}
+	} else {
+		m, err = manager.Single(config)
+	}
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
+	return m
+}
 
-	var opt = new(trade.Option)
-	err = yaml.NewDecoder(bytes.NewReader(data)).Decode(opt)
+func loadOption(file string) *trade.Option {
+	opt, err := trade.LoadOption(file)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
+	return opt
+}
 
-	opt.BuyOption.SameCoinBlockDuration = opt.BuyOption.SameCoinBlockDuration * time.Second
-	opt.SellOption.Interval = opt.SellOption.Interval * time.Second
-	opt.SellOption.StopLossDuration = opt.SellOption.StopLossDuration * time.Second
-	opt.BuyOption.Interval = opt.BuyOption.Interval * time.Second
-
-	t := trade.NewTrade(
-		trade.WithBuyOption(opt.BuyOption),
-		trade.WithSellOption(opt.SellOption),
-		trade.WithSystemOption(opt.SystemOption),
-	)
-
-	ch := make(chan os.Signal)
-	stopCh := make(chan struct{})
-
-	t.AfterBuy = func(order *binance.Order) {
-		logrus.Infof("buy %s", order.Symbol)
-	}
+func main() {
+	flag.Parse()
 
-	t.AfterSell = func(info *trade.SellBill) {
-		logrus.Info("sell %s - %s", info.Info.Symbol, info.Reason)
+	switch flag.Arg(0) {
+	case "backtest":
+		backtest(flag.Args()[1:])
+		return
+	case "sell", "buy", "liquidate", "pause", "resume", "start", "stop":
+		control(flag.Arg(0), flag.Args()[1:])
+		return
 	}
 
-	go func() {
-		if err := t.Run(stopCh); err != nil {
-			log.Fatal(err)
+	m := loadManager()
+	for _, b := range m.Bots() {
+		name := b.Name
+		b.Trade.AfterBuy = func(order *binance.Order) {
+			logrus.Infof("%s: buy %s", name, order.Symbol)
+		}
+		b.Trade.AfterSell = func(info *trade.SellBill) {
+			logrus.Infof("%s: sell %s - %s", name, info.Info.Symbol, info.Reason)
 		}
-	}()
+	}
+	if err := m.Start(); err != nil {
+		log.Fatal(err)
+	}
 
+	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt, os.Kill)
 	<-ch
-	close(stopCh)
+	m.Stop()
 	time.Sleep(3 * time.Second)
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"github.com/clearcodecn/binance-bot/pkg/manager"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// control calls the control api of the running bot, eg:
+// bot sell DOGEUSDT
+// bot buy DOGEUSDT
+// bot liquidate
+// bot pause
+// bot resume
+// bot -bots bots.yaml -bot aggressive stop
+// bot -bots bots.yaml -bot aggressive start
+func control(command string, args []string) {
+	prefix := "/api"
+	if bot != "" {
+		prefix = "/api/bots/" + bot
+	}
+	var path string
+	switch command {
+	case "sell", "buy":
+		if len(args) != 1 {
+			log.Fatalf("usage: bot %s SYMBOL", command)
+		}
+		symbol := strings.ToUpper(args[0])
+		if command == "sell" {
+			path = prefix + "/positions/" + symbol + "/sell"
+		} else {
+			path = prefix + "/buy/" + symbol
+		}
+	case "start", "stop":
+		if bot == "" {
+			log.Fatalf("usage: bot -bot NAME %s", command)
+		}
+		path = prefix + "/" + command
+	default:
+		path = prefix + "/" + command
+	}
+
+	if token == "" {
+		token = controlToken()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(addr, "/")+path, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	fmt.Println(string(body))
+	if resp.StatusCode >= 300 {
+		os.Exit(1)
+	}
+}
+
+// controlToken returns the APIToken in the config file of the bot, empty if it is not found.
+func controlToken() string {
+	file := config
+	if bots != "" {
+		c, err := manager.LoadConfig(bots)
+		if err != nil {
+			log.Fatal(err)
+		}
+		file = ""
+		for _, b := range c.Bots {
+			if b.Name == bot || (bot == "" && file == "") {
+				file = b.Config
+			}
+		}
+	}
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+	return loadOption(file).SystemOption.APIToken
+}
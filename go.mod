@@ -6,7 +6,9 @@ require (
 	github.com/adshao/go-binance/v2 v2.2.1
 	github.com/ghodss/yaml v1.0.0
 	github.com/gin-gonic/gin v1.7.1
+	github.com/gorilla/websocket v1.5.3
 	github.com/hashicorp/golang-lru v0.5.4
+	github.com/prometheus/client_golang v1.11.1
 	github.com/sirupsen/logrus v1.8.1
-	gopkg.in/yaml.v2 v2.2.8
+	go.etcd.io/bbolt v1.3.6
 )
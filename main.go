@@ -11,22 +11,35 @@ import (
 
 var (
 	config string
+	bots   string
 )
 
 func init() {
 	flag.StringVar(&config, "c", "config.yaml", "config file")
+	flag.StringVar(&bots, "bots", "", "bots file running several bots with their own config files, -c is ignored if set")
 }
 
 func main() {
 	flag.Parse()
 
-	server := http.NewServer(config)
+	var (
+		server *http.Server
+		err    error
+	)
+	if bots != "" {
+		server, err = http.NewBotsServer(bots)
+	} else {
+		server, err = http.NewServer(config)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 	stopCh := make(chan struct{})
 	go func() {
 		log.Fatal(server.Run(stopCh, ":8080"))
 	}()
 
-	ch := make(chan os.Signal)
+	ch := make(chan os.Signal, 1)
 	signal.Notify(ch, os.Interrupt, os.Kill)
 	<-ch
 	close(stopCh)
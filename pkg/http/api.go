@@ -0,0 +1,278 @@
+package http
+
+import (
+	"crypto/subtle"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *Server) registerAPI(g *gin.RouterGroup) {
+	g.GET("/positions", s.Positions)
+	g.GET("/option", s.Option)
+	g.GET("/sells", s.Sells)
+	g.GET("/blocked", s.Blocked)
+	g.GET("/universe", s.Universe)
+	g.GET("/status", s.Status)
+	g.GET("/events", s.Events)
+	g.GET("/journal", s.JournalEntries)
+	g.GET("/pnl", s.PnL)
+
+	control := g.Group("", s.authenticate)
+	control.POST("/positions/:symbol/sell", s.SellPosition)
+	control.POST("/buy/:symbol", s.BuySymbol)
+	control.POST("/liquidate", s.Liquidate)
+	control.POST("/pause", s.Pause)
+	control.POST("/resume", s.Resume)
+	control.POST("/config/reload", s.ReloadConfig)
+
+	g.POST("/signals", s.authenticateSignal, s.Signal)
+}
+
+// authenticate requires the APIToken as a bearer token
+func (s *Server) authenticate(ctx *gin.Context) {
+	token := s.trade.Option().SystemOption.APIToken
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "control api is disabled, set SystemOption.APIToken to enable it"})
+		return
+	}
+	given := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	ctx.Next()
+}
+
+// Status returns whether the bot is running, buying is paused and how many positions we hold
+func (s *Server) Status(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"running":   s.bot.Running(),
+		"paused":    s.trade.Paused(),
+		"positions": len(s.trade.CachedPositions()),
+	})
+}
+
+// SellPosition enqueues a manual sell of the position
+func (s *Server) SellPosition(ctx *gin.Context) {
+	symbol := strings.ToUpper(ctx.Param("symbol"))
+	if err := s.trade.SellNow(ctx.Request.Context(), symbol); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"symbol": symbol})
+}
+
+// BuySymbol buys the symbol at the current price
+func (s *Server) BuySymbol(ctx *gin.Context) {
+	symbol := strings.ToUpper(ctx.Param("symbol"))
+	info, err := s.trade.BuyNow(ctx.Request.Context(), symbol)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, info)
+}
+
+// Liquidate enqueues manual sells of all positions
+func (s *Server) Liquidate(ctx *gin.Context) {
+	count, err := s.trade.LiquidateAll(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "count": count})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"count": count})
+}
+
+// Pause stops buying, TP/SL checks keep running
+func (s *Server) Pause(ctx *gin.Context) {
+	s.trade.Pause()
+	ctx.JSON(http.StatusOK, gin.H{"paused": true})
+}
+
+// Resume starts buying again
+func (s *Server) Resume(ctx *gin.Context) {
+	s.trade.Resume()
+	ctx.JSON(http.StatusOK, gin.H{"paused": false})
+}
+
+// ReloadConfig reloads the config file, the option is kept if it is invalid
+func (s *Server) ReloadConfig(ctx *gin.Context) {
+	changes, err := s.trade.ReloadConfig()
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if changes == nil {
+		changes = []string{}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"changes": changes})
+}
+
+// PnL returns the realised PnL in total, per symbol and per day
+func (s *Server) PnL(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.trade.PnL())
+}
+
+// Positions lists the open positions with current price and unrealised PnL
+func (s *Server) Positions(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.trade.Positions(ctx.Request.Context()))
+}
+
+// Option returns the effective option, secrets are hidden.
+func (s *Server) Option(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.option())
+}
+
+func (s *Server) option() trade.Option {
+	option := s.trade.Option()
+	option.SystemOption.AccessKey = hideSecret(option.SystemOption.AccessKey)
+	option.SystemOption.SecretKey = hideSecret(option.SystemOption.SecretKey)
+	option.SystemOption.APIToken = hideSecret(option.SystemOption.APIToken)
+	option.SystemOption.SignalToken = hideSecret(option.SystemOption.SignalToken)
+	option.SystemOption.TelegramToken = hideSecret(option.SystemOption.TelegramToken)
+	webhooks := make([]trade.WebhookOption, len(option.SystemOption.Webhooks))
+	for i, w := range option.SystemOption.Webhooks {
+		w.Secret = hideSecret(w.Secret)
+		webhooks[i] = w
+	}
+	option.SystemOption.Webhooks = webhooks
+	return option
+}
+
+type sellView struct {
+	*trade.SellBill
+	ReasonText string `json:"reasonText"`
+
+	// PnL the realised PnL with commissions
+	PnL float64 `json:"pnl"`
+}
+
+// Sells lists the recent sell bills, newest first, ?limit=N
+func (s *Server) Sells(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	ctx.JSON(http.StatusOK, s.sells(limit))
+}
+
+func (s *Server) sells(limit int) []sellView {
+	var views = make([]sellView, 0)
+	for _, bill := range s.trade.SellHistory(limit) {
+		view := sellView{
+			SellBill:   bill,
+			ReasonText: bill.Reason.String(),
+		}
+		if bill.PnL != nil {
+			view.PnL = bill.PnL.PnL
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+type blockedView struct {
+	Symbol string    `json:"symbol"`
+	Until  time.Time `json:"until"`
+}
+
+// Universe lists the symbols the bot may buy.
+func (s *Server) Universe(ctx *gin.Context) {
+	symbols := s.trade.Universe()
+	if symbols == nil {
+		symbols = make([]string, 0)
+	}
+	ctx.JSON(http.StatusOK, symbols)
+}
+
+// Blocked lists the sold symbols we will not buy again until the time
+func (s *Server) Blocked(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, s.blocked())
+}
+
+func (s *Server) blocked() []blockedView {
+	var views = make([]blockedView, 0)
+	for symbol, until := range s.trade.BlockedSymbols() {
+		views = append(views, blockedView{Symbol: symbol, Until: until})
+	}
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].Until.Before(views[j].Until)
+	})
+	return views
+}
+
+// JournalEntries queries the trade journal, ?symbol=&type=buy|sell&since=RFC3339&until=RFC3339&limit=N
+func (s *Server) JournalEntries(ctx *gin.Context) {
+	journal := s.trade.Journal()
+	if journal == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "journal is disabled, set SystemOption.JournalFile to enable it"})
+		return
+	}
+
+	var (
+		q   trade.JournalQuery
+		err error
+	)
+	q.Symbol = strings.ToUpper(ctx.Query("symbol"))
+	q.Type = trade.JournalEntryType(ctx.Query("type"))
+	q.Limit, _ = strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if since := ctx.Query("since"); since != "" {
+		if q.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if until := ctx.Query("until"); until != "" {
+		if q.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	entries, err := journal.Entries(q)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// eventInterval how often the dashboard is updated
+const eventInterval = time.Second
+
+// Events pushes a snapshot of the bot to the dashboard by server-sent events,
+// the positions are priced from the price book so open dashboards send no requests to binance.
+func (s *Server) Events(ctx *gin.Context) {
+	ticker := time.NewTicker(eventInterval)
+	defer ticker.Stop()
+
+	first := make(chan struct{}, 1)
+	first <- struct{}{}
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-first:
+		case <-ticker.C:
+		}
+		ctx.SSEvent("snapshot", gin.H{
+			"positions": s.trade.CachedPositions(),
+			"sells":     s.sells(50),
+			"blocked":   s.blocked(),
+			"option":    s.option(),
+			"paused":    s.trade.Paused(),
+			"pnl":       s.trade.PnL().Total,
+		})
+		return true
+	})
+}
+
+func hideSecret(secret string) string {
+	if len(secret) <= 4 {
+		return ""
+	}
+	return secret[:4] + "****"
+}
@@ -0,0 +1,291 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testToken       = "token"
+	testSignalToken = "signal-token"
+)
+
+func newTestServer(t *testing.T) (*Server, *trade.SimExchange) {
+	gin.SetMode(gin.TestMode)
+
+	boughtFile := filepath.Join(t.TempDir(), "trade.json")
+	bought := `{"DOGEUSDT":{"symbol":"DOGEUSDT","orderId":1,"price":0,"takeProfit":2,"volume":20,"executedQuantity":20,"cummulativeQuoteQuantity":10,"lotSize":0}}`
+	if err := ioutil.WriteFile(boughtFile, []byte(bought), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sim := trade.NewSimExchange(map[string]float64{"USDT": 100, "DOGE": 20})
+	sim.AddSymbol(trade.SimSymbol{Symbol: "DOGEUSDT", BaseAsset: "DOGE", QuoteAsset: "USDT"})
+	sim.SetPrice("DOGEUSDT", 0.55)
+
+	tr := trade.NewTrade(
+		trade.WithBuyOption(trade.BuyOption{
+			Interval:   time.Second,
+			MaxBuy:     2,
+			MainCoin:   "USDT",
+			BoughtFile: boughtFile,
+		}),
+		trade.WithSellOption(trade.DefaultSellOption),
+		trade.WithSystemOption(trade.SystemOption{AccessKey: "access-key", SecretKey: "secret-key", APIToken: testToken, SignalToken: testSignalToken}),
+	)
+	tr.SetExchange(sim)
+
+	return newServer(tr, gin.New()), sim
+}
+
+func (s *Server) do(t *testing.T, method, path string, v interface{}) int {
+	req := httptest.NewRequest(method, path, nil)
+	if method != http.MethodGet {
+		req.Header.Set("Authorization", "Bearer "+testToken)
+	}
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: %v: %s", method, path, err, w.Body.String())
+		}
+	}
+	return w.Code
+}
+
+func TestPositions(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	var positions []*trade.Position
+	if code := s.do(t, http.MethodGet, "/api/positions", &positions); code != http.StatusOK {
+		t.Fatalf("unexpected code %d", code)
+	}
+	if len(positions) != 1 {
+		t.Fatalf("expected 1 position, got %d", len(positions))
+	}
+	p := positions[0]
+	if p.Symbol != "DOGEUSDT" || p.BuyPrice != 0.5 || p.LastPrice != 0.55 {
+		t.Fatalf("unexpected position %+v", p)
+	}
+	if diff := p.UnrealisedPnL - 1; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("expected unrealised pnl 1, got %f", p.UnrealisedPnL)
+	}
+}
+
+func TestOptionHidesSecrets(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	var option trade.Option
+	s.do(t, http.MethodGet, "/api/option", &option)
+	if option.SystemOption.SecretKey != "secr****" || option.BuyOption.MainCoin != "USDT" {
+		t.Fatalf("unexpected option %+v", option)
+	}
+}
+
+func TestSellsAndBlockedEmpty(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	var list []interface{}
+	s.do(t, http.MethodGet, "/api/sells", &list)
+	if list == nil || len(list) != 0 {
+		t.Fatalf("expected an empty list, got %v", list)
+	}
+	s.do(t, http.MethodGet, "/api/blocked", &list)
+	if list == nil || len(list) != 0 {
+		t.Fatalf("expected an empty list, got %v", list)
+	}
+}
+
+func TestControlRequiresToken(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/pause", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if s.trade.Paused() {
+		t.Fatal("should not be paused")
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	var status struct {
+		Paused bool `json:"paused"`
+	}
+	s.do(t, http.MethodPost, "/api/pause", nil)
+	s.do(t, http.MethodGet, "/api/status", &status)
+	if !status.Paused {
+		t.Fatal("expected paused")
+	}
+	s.do(t, http.MethodPost, "/api/resume", nil)
+	s.do(t, http.MethodGet, "/api/status", &status)
+	if status.Paused {
+		t.Fatal("expected resumed")
+	}
+}
+
+func TestManualSell(t *testing.T) {
+	s, sim := newTestServer(t)
+
+	if code := s.do(t, http.MethodPost, "/api/positions/BTCUSDT/sell", nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown position, got %d", code)
+	}
+	if code := s.do(t, http.MethodPost, "/api/positions/dogeusdt/sell", nil); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := s.trade.Run(stopCh); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var sells []struct {
+			Reason trade.SellReason `json:"reason"`
+		}
+		s.do(t, http.MethodGet, "/api/sells", &sells)
+		if len(sells) == 1 {
+			if sells[0].Reason != trade.SellReasonManual {
+				t.Fatalf("expected manual sell, got %s", sells[0].Reason)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the position to be sold")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if sim.Balance("USDT") <= 100 {
+		t.Fatalf("expected USDT back, got %f", sim.Balance("USDT"))
+	}
+
+	var report trade.PnLReport
+	if code := s.do(t, http.MethodGet, "/api/pnl", &report); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if report.Total.Trades != 1 || report.Symbols["DOGEUSDT"] == nil {
+		t.Fatalf("unexpected pnl %+v", report)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "EventSource") {
+		t.Fatalf("unexpected dashboard %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	server := httptest.NewServer(s.engine)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "event:snapshot\n" {
+		t.Fatalf("unexpected event %q", line)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	post := func(path, body string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.engine.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := post("/api/signals", `{"action":"close","symbol":"DOGEUSDT"}`); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", code)
+	}
+	// the APIToken grants the control endpoints, it is never taken in the url.
+	if code := post("/api/signals?token="+testToken, `{"action":"close","symbol":"DOGEUSDT"}`); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with the api token, got %d", code)
+	}
+	if code := post("/api/signals?token="+testSignalToken, `{"action":"buy","symbol":"ETHUSDT"}`); code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 out of the white list, got %d", code)
+	}
+	if code := post("/api/signals?token="+testSignalToken, `{"action":"close","symbol":"dogeusdt"}`); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/liquidate", nil)
+	req.Header.Set("Authorization", "Bearer "+testSignalToken)
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 liquidating with the signal token, got %d", w.Code)
+	}
+}
+
+func TestSignalTokenNotLogged(t *testing.T) {
+	var logs bytes.Buffer
+	gin.DefaultWriter = &logs
+	defer func() { gin.DefaultWriter = os.Stdout }()
+
+	s, _ := newTestServer(t)
+	g := newEngine()
+	newServer(s.trade, g)
+	req := httptest.NewRequest(http.MethodPost, "/api/signals?symbol=DOGEUSDT&token="+testSignalToken, strings.NewReader(`{"action":"close","symbol":"DOGEUSDT"}`))
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", w.Code)
+	}
+	if !strings.Contains(logs.String(), "/api/signals?symbol=DOGEUSDT") || strings.Contains(logs.String(), testSignalToken) {
+		t.Fatalf("expected the token stripped from the log: %s", logs.String())
+	}
+}
+
+func TestReloadConfigWithoutFile(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	var resp struct {
+		Error string `json:"error"`
+	}
+	if code := s.do(t, http.MethodPost, "/api/config/reload", &resp); code != http.StatusUnprocessableEntity || resp.Error == "" {
+		t.Fatalf("expected the reload rejected, got %d %q", code, resp.Error)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	s.engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "binance_bot_open_positions") {
+		t.Fatalf("unexpected metrics %d: %s", w.Code, w.Body.String())
+	}
+}
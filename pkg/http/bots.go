@@ -0,0 +1,67 @@
+package http
+
+import (
+	"errors"
+	"github.com/clearcodecn/binance-bot/pkg/manager"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// registerBot serves the api of the bot, with the control endpoints starting and stopping it.
+func (s *Server) registerBot(g *gin.RouterGroup) {
+	s.registerAPI(g)
+
+	control := g.Group("", s.authenticate)
+	control.POST("/start", s.StartBot)
+	control.POST("/stop", s.StopBot)
+}
+
+type botView struct {
+	Name      string           `json:"name"`
+	Running   bool             `json:"running"`
+	Paused    bool             `json:"paused"`
+	Positions int              `json:"positions"`
+	PnL       trade.PnLSummary `json:"pnl"`
+	Asset     string           `json:"asset"`
+}
+
+// Bots lists the bots with their state
+func (s *Server) Bots(ctx *gin.Context) {
+	var views = make([]botView, 0)
+	for _, b := range s.manager.Bots() {
+		pnl := b.Trade.PnL()
+		views = append(views, botView{
+			Name:      b.Name,
+			Running:   b.Running(),
+			Paused:    b.Trade.Paused(),
+			Positions: len(b.Trade.CachedPositions()),
+			PnL:       pnl.Total,
+			Asset:     pnl.Asset,
+		})
+	}
+	ctx.JSON(http.StatusOK, views)
+}
+
+// StartBot starts buying and selling of the bot
+func (s *Server) StartBot(ctx *gin.Context) {
+	err := s.bot.Start()
+	if errors.Is(err, manager.ErrRunning) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"running": true})
+}
+
+// StopBot stops the bot, its positions are kept
+func (s *Server) StopBot(ctx *gin.Context) {
+	if err := s.bot.Stop(); err != nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"running": false})
+}
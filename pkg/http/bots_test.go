@@ -0,0 +1,92 @@
+package http
+
+import (
+	"github.com/clearcodecn/binance-bot/pkg/manager"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestBot(t *testing.T, name, bought string) *manager.Bot {
+	boughtFile := filepath.Join(t.TempDir(), "trade.json")
+	if err := ioutil.WriteFile(boughtFile, []byte(bought), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sim := trade.NewSimExchange(map[string]float64{"USDT": 100, "DOGE": 20})
+	sim.AddSymbol(trade.SimSymbol{Symbol: "DOGEUSDT", BaseAsset: "DOGE", QuoteAsset: "USDT"})
+	sim.SetPrice("DOGEUSDT", 0.55)
+
+	tr := trade.NewTrade(
+		trade.WithBuyOption(trade.BuyOption{
+			Interval:   time.Second,
+			MaxBuy:     2,
+			MainCoin:   "USDT",
+			BoughtFile: boughtFile,
+		}),
+		trade.WithSellOption(trade.DefaultSellOption),
+		trade.WithSystemOption(trade.SystemOption{APIToken: testToken, Name: name}),
+	)
+	tr.SetExchange(sim)
+	return &manager.Bot{Name: name, Trade: tr}
+}
+
+func TestBots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m, err := manager.New(
+		newTestBot(t, "conservative", `{}`),
+		newTestBot(t, "aggressive", `{"DOGEUSDT":{"symbol":"DOGEUSDT","orderId":1,"takeProfit":2,"volume":20,"executedQuantity":20,"cummulativeQuoteQuantity":10}}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newManagerServer(m, gin.New())
+	defer m.Stop()
+
+	var positions []*trade.Position
+	s.do(t, http.MethodGet, "/api/bots/aggressive/positions", &positions)
+	if len(positions) != 1 {
+		t.Fatalf("expected the position of aggressive, got %d", len(positions))
+	}
+	// the first bot is served without a name.
+	s.do(t, http.MethodGet, "/api/positions", &positions)
+	if len(positions) != 0 {
+		t.Fatalf("expected no position of conservative, got %d", len(positions))
+	}
+
+	s.do(t, http.MethodPost, "/api/bots/aggressive/pause", nil)
+	if code := s.do(t, http.MethodPost, "/api/bots/aggressive/start", nil); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := s.do(t, http.MethodPost, "/api/bots/aggressive/start", nil); code != http.StatusConflict {
+		t.Fatalf("expected 409 starting a running bot, got %d", code)
+	}
+
+	var bots []struct {
+		Name      string `json:"name"`
+		Running   bool   `json:"running"`
+		Paused    bool   `json:"paused"`
+		Positions int    `json:"positions"`
+	}
+	s.do(t, http.MethodGet, "/api/bots", &bots)
+	if len(bots) != 2 || bots[0].Name != "conservative" || bots[1].Name != "aggressive" {
+		t.Fatalf("unexpected bots %+v", bots)
+	}
+	if bots[0].Running || bots[0].Paused || !bots[1].Running || !bots[1].Paused || bots[1].Positions != 1 {
+		t.Fatalf("the bots should be independent, got %+v", bots)
+	}
+
+	if code := s.do(t, http.MethodPost, "/api/bots/aggressive/stop", nil); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := s.do(t, http.MethodPost, "/api/bots/aggressive/stop", nil); code != http.StatusConflict {
+		t.Fatalf("expected 409 stopping a stopped bot, got %d", code)
+	}
+	if code := s.do(t, http.MethodGet, "/api/bots/unknown/positions", nil); code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+}
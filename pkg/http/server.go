@@ -1,57 +1,85 @@
 package http
 
 import (
-	"bytes"
+	"github.com/clearcodecn/binance-bot/pkg/manager"
 	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/clearcodecn/binance-bot/web"
 	"github.com/gin-gonic/gin"
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
-	"time"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"html/template"
 )
 
+// Server serves the api of a bot, the root server serves the default bot under /api
+// and every bot under /api/bots/{name}.
 type Server struct {
-	engine *gin.Engine
-	trade  *trade.Trade
+	engine  *gin.Engine
+	manager *manager.Manager
+
+	bot   *manager.Bot
+	trade *trade.Trade
 }
 
+// Run starts the bots not disabled, they are stopped once stopChan is closed.
 func (s *Server) Run(stopChan chan struct{}, addr string) error {
-	if err := s.trade.Run(stopChan); err != nil {
+	if err := s.manager.Start(); err != nil {
 		return err
 	}
+	go func() {
+		<-stopChan
+		s.manager.Stop()
+	}()
 	return s.engine.Run(addr)
 }
 
-func NewServer(config string) *Server {
-	s := new(Server)
-	data, err := ioutil.ReadFile(config)
+// NewServer returns a server running the bot of the config file.
+func NewServer(config string) (*Server, error) {
+	m, err := manager.Single(config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	return newManagerServer(m, newEngine()), nil
+}
 
-	var opt = new(trade.Option)
-	err = yaml.NewDecoder(bytes.NewReader(data)).Decode(opt)
+// NewBotsServer returns a server running the bots of the bots file, see manager.Config.
+func NewBotsServer(bots string) (*Server, error) {
+	config, err := manager.LoadConfig(bots)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	m, err := manager.Load(config)
+	if err != nil {
+		return nil, err
+	}
+	return newManagerServer(m, newEngine()), nil
+}
 
-	g := gin.Default()
-	g.LoadHTMLGlob("web/*.html")
-	g.GET("/", s.Index)
-	s.engine = g
-
-	// set durations.
-	opt.BuyOption.SameCoinBlockDuration = opt.BuyOption.SameCoinBlockDuration * time.Second
-	opt.SellOption.Interval = opt.SellOption.Interval * time.Second
-	opt.SellOption.StopLossDuration = opt.SellOption.StopLossDuration * time.Second
-	opt.BuyOption.Interval = opt.BuyOption.Interval * time.Second
+// newEngine returns an engine with the middlewares of gin.Default, the ?token= of signals is stripped before logging.
+func newEngine() *gin.Engine {
+	g := gin.New()
+	g.Use(stripQueryToken, gin.Logger(), gin.Recovery())
+	return g
+}
 
-	t := trade.NewTrade(
-		trade.WithBuyOption(opt.BuyOption),
-		trade.WithSellOption(opt.SellOption),
-		trade.WithSystemOption(opt.SystemOption),
-	)
+func newServer(t *trade.Trade, g *gin.Engine) *Server {
+	m, err := manager.New(&manager.Bot{Name: manager.DefaultBot, Trade: t})
+	if err != nil {
+		panic(err)
+	}
+	return newManagerServer(m, g)
+}
 
-	s.trade = t
+func newManagerServer(m *manager.Manager, g *gin.Engine) *Server {
+	s := &Server{engine: g, manager: m, bot: m.Default(), trade: m.Default().Trade}
+	g.SetHTMLTemplate(template.Must(template.ParseFS(web.FS, "*.html")))
+	g.GET("/", s.Index)
+	g.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	api := g.Group("/api")
+	s.registerAPI(api)
+	api.GET("/bots", s.Bots)
+	for _, b := range m.Bots() {
+		bs := &Server{engine: g, manager: m, bot: b, trade: b.Trade}
+		bs.registerBot(api.Group("/bots/" + b.Name))
+	}
 	return s
 }
 
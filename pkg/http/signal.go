@@ -0,0 +1,69 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// queryTokenKey the context key of the ?token= stripped from the url by stripQueryToken
+const queryTokenKey = "queryToken"
+
+// stripQueryToken takes ?token= out of the url before the request is logged, it is kept for authenticateSignal.
+func stripQueryToken(ctx *gin.Context) {
+	query := ctx.Request.URL.Query()
+	if token := query.Get("token"); token != "" {
+		ctx.Set(queryTokenKey, token)
+		query.Del("token")
+		ctx.Request.URL.RawQuery = query.Encode()
+	}
+	ctx.Next()
+}
+
+// authenticateSignal requires the SignalToken, as a bearer token or as ?token= for clients unable to set headers,
+// eg: TradingView alerts. The SignalToken only grants signals, the control endpoints need the APIToken.
+func (s *Server) authenticateSignal(ctx *gin.Context) {
+	token := s.trade.Option().SystemOption.SignalToken
+	if token == "" {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "signals are disabled, set SystemOption.SignalToken to enable them"})
+		return
+	}
+	given := ctx.GetString(queryTokenKey)
+	if given == "" {
+		given = ctx.Query("token")
+	}
+	if given == "" {
+		given = strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+	ctx.Next()
+}
+
+// Signal executes an external signal: {"action":"buy|sell|close","symbol":"DOGEUSDT","size":20,"takeProfit":3,"stopLoss":1}
+func (s *Server) Signal(ctx *gin.Context) {
+	var signal trade.ExternalSignal
+	if err := ctx.ShouldBindJSON(&signal); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	info, err := s.trade.HandleSignal(ctx.Request.Context(), signal)
+	if errors.Is(err, trade.ErrSignalRejected) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if info == nil {
+		ctx.JSON(http.StatusAccepted, gin.H{"symbol": signal.Symbol, "action": signal.Action})
+		return
+	}
+	ctx.JSON(http.StatusOK, info)
+}
@@ -0,0 +1,264 @@
+// Package manager runs several independently configured bots in one process,
+// each with its own keys, options, positions and logger.
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/clearcodecn/binance-bot/pkg/telegram"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/clearcodecn/binance-bot/pkg/webhook"
+	"github.com/ghodss/yaml"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// DefaultBot the name of the bot run from a single config file
+const DefaultBot = "default"
+
+var (
+	ErrBotNotFound = errors.New("bot not found")
+	ErrRunning     = errors.New("bot is running")
+	ErrStopped     = errors.New("bot is stopped")
+)
+
+// validName the names are used in the api paths.
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Config defines the bots run by the manager
+type Config struct {
+	Bots []BotConfig
+}
+
+// BotConfig defines a bot
+type BotConfig struct {
+	// Name the bot is addressed by in the api and the logs, eg: conservative
+	Name string
+
+	// Config the config file of the bot, the same format as a single bot.
+	Config string
+
+	// Disabled the bot is loaded, but not started with the manager.
+	Disabled bool
+}
+
+// LoadConfig loads the bots of the yaml file, relative config files are resolved from its directory.
+func LoadConfig(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	for i, b := range config.Bots {
+		if b.Config != "" && !filepath.IsAbs(b.Config) {
+			config.Bots[i].Config = filepath.Join(filepath.Dir(file), b.Config)
+		}
+	}
+	return &config, nil
+}
+
+// Bot defines a named trade with its notifiers, started and stopped on its own.
+type Bot struct {
+	Name     string
+	Config   string
+	Disabled bool
+	Trade    *trade.Trade
+
+	notifier *telegram.Notifier
+
+	mu       sync.Mutex
+	stopChan chan struct{}
+}
+
+// NewBot loads the config file and returns the bot, it is not started.
+func NewBot(name, config string) (*Bot, error) {
+	opt, err := trade.LoadOption(config)
+	if err != nil {
+		return nil, err
+	}
+	opt.SystemOption.Name = name
+
+	t := trade.NewTrade(
+		trade.WithBuyOption(opt.BuyOption),
+		trade.WithSellOption(opt.SellOption),
+		trade.WithSystemOption(opt.SystemOption),
+	)
+	b := &Bot{Name: name, Config: config, Trade: t}
+	if b.notifier = telegram.New(t); b.notifier != nil {
+		t.AddHook(b.notifier)
+	}
+	if n := webhook.New(t); n != nil {
+		t.AddHook(n)
+	}
+	return b, nil
+}
+
+// Start runs the trade, the config watcher and the telegram commands of the bot.
+func (b *Bot) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopChan != nil {
+		return ErrRunning
+	}
+	stopChan := make(chan struct{})
+	if err := b.Trade.Run(stopChan); err != nil {
+		close(stopChan)
+		return err
+	}
+	if b.Config != "" {
+		go b.Trade.WatchConfig(stopChan, b.Config)
+	}
+	if b.notifier != nil {
+		go b.notifier.Run(stopChan)
+	}
+	b.stopChan = stopChan
+	b.Trade.Logger().Infof("bot %s started", b.Name)
+	return nil
+}
+
+// Stop stops buying and selling, the positions are kept and watched again on the next Start.
+func (b *Bot) Stop() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopChan == nil {
+		return ErrStopped
+	}
+	close(b.stopChan)
+	b.stopChan = nil
+	b.Trade.Logger().Infof("bot %s stopped", b.Name)
+	return nil
+}
+
+// Running reports whether the bot is started
+func (b *Bot) Running() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.stopChan != nil
+}
+
+// Manager holds the bots by name
+type Manager struct {
+	bots  map[string]*Bot
+	names []string
+}
+
+// Load loads the bots of the config.
+func Load(config *Config) (*Manager, error) {
+	var bots []*Bot
+	for _, c := range config.Bots {
+		if !validName.MatchString(c.Name) {
+			return nil, fmt.Errorf("bot name %q must be letters, digits, _ or -", c.Name)
+		}
+		b, err := NewBot(c.Name, c.Config)
+		if err != nil {
+			return nil, fmt.Errorf("bot %s: %w", c.Name, err)
+		}
+		b.Disabled = c.Disabled
+		bots = append(bots, b)
+	}
+	return New(bots...)
+}
+
+// New returns a manager of the bots. The bots must not share their bought or journal files,
+// the positions of a bot are only known to it.
+func New(bots ...*Bot) (*Manager, error) {
+	if len(bots) == 0 {
+		return nil, errors.New("no bots configured")
+	}
+	var (
+		m       = &Manager{bots: make(map[string]*Bot)}
+		bought  = make(map[string]string)
+		journal = make(map[string]string)
+	)
+	for _, b := range bots {
+		if !validName.MatchString(b.Name) {
+			return nil, fmt.Errorf("bot name %q must be letters, digits, _ or -", b.Name)
+		}
+		if _, ok := m.bots[b.Name]; ok {
+			return nil, fmt.Errorf("bot %s is duplicated", b.Name)
+		}
+		option := b.Trade.Option()
+		if f := option.BuyOption.BoughtFile; f != "" {
+			if other, ok := bought[absPath(f)]; ok {
+				return nil, fmt.Errorf("bot %s: BuyOption.BoughtFile %s is used by bot %s", b.Name, f, other)
+			}
+			bought[absPath(f)] = b.Name
+		}
+		if f := option.SystemOption.JournalFile; f != "" {
+			if other, ok := journal[absPath(f)]; ok {
+				return nil, fmt.Errorf("bot %s: SystemOption.JournalFile %s is used by bot %s", b.Name, f, other)
+			}
+			journal[absPath(f)] = b.Name
+		}
+		m.bots[b.Name] = b
+		m.names = append(m.names, b.Name)
+	}
+	return m, nil
+}
+
+// absPath returns the absolute path of the file, so the same file written differently is found.
+func absPath(f string) string {
+	if abs, err := filepath.Abs(f); err == nil {
+		return abs
+	}
+	return filepath.Clean(f)
+}
+
+// Single returns a manager of the bot of a single config file, named DefaultBot.
+func Single(config string) (*Manager, error) {
+	return Load(&Config{Bots: []BotConfig{{Name: DefaultBot, Config: config}}})
+}
+
+// Bot returns the bot by name
+func (m *Manager) Bot(name string) (*Bot, error) {
+	b, ok := m.bots[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBotNotFound, name)
+	}
+	return b, nil
+}
+
+// Bots returns the bots in the order of the config
+func (m *Manager) Bots() []*Bot {
+	var bots []*Bot
+	for _, name := range m.names {
+		bots = append(bots, m.bots[name])
+	}
+	return bots
+}
+
+// Default returns the first bot, the one served without a name.
+func (m *Manager) Default() *Bot {
+	return m.bots[m.names[0]]
+}
+
+// Start starts the bots not disabled, it stops them all if one fails.
+func (m *Manager) Start() error {
+	for _, b := range m.Bots() {
+		if b.Disabled {
+			continue
+		}
+		if err := b.Start(); err != nil {
+			m.Stop()
+			return fmt.Errorf("bot %s: %w", b.Name, err)
+		}
+	}
+	return nil
+}
+
+// Stop stops the running bots.
+func (m *Manager) Stop() {
+	for _, b := range m.Bots() {
+		if b.Running() {
+			b.Stop()
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+BuyOption:
+  Interval: 1s
+  MaxBuy: 2
+  MoneyPerOrder: 11
+  MainCoin: USDT
+  WhiteList: [DOGE]
+  BoughtFile: BOUGHT
+SellOption:
+  Interval: 1s
+  TakeProfit: 2
+  StopLoss: 1.5
+`
+
+func writeFile(t *testing.T, file, content string) {
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "conservative.yaml"), strings.Replace(testConfig, "BOUGHT", filepath.Join(dir, "conservative.json"), 1))
+	writeFile(t, filepath.Join(dir, "aggressive.yaml"), strings.Replace(testConfig, "BOUGHT", filepath.Join(dir, "aggressive.json"), 1))
+	writeFile(t, filepath.Join(dir, "bots.yaml"), `
+Bots:
+  - Name: conservative
+    Config: conservative.yaml
+  - Name: aggressive
+    Config: aggressive.yaml
+    Disabled: true
+`)
+
+	config, err := LoadConfig(filepath.Join(dir, "bots.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the config files are relative to the bots file.
+	if config.Bots[1].Config != filepath.Join(dir, "aggressive.yaml") || !config.Bots[1].Disabled {
+		t.Fatalf("unexpected bots %+v", config.Bots)
+	}
+	m, err := Load(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Bots()) != 2 || m.Default().Name != "conservative" {
+		t.Fatalf("unexpected bots %v", m.Bots())
+	}
+	b, err := m.Bot("aggressive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := b.Trade.Option().SystemOption.Name; name != "aggressive" {
+		t.Fatalf("expected the trade named aggressive, got %q", name)
+	}
+	if _, err := m.Bot("unknown"); err == nil {
+		t.Fatal("expected bot not found")
+	}
+
+	// both bots would trade the same positions.
+	config.Bots[1].Config = config.Bots[0].Config
+	if _, err := Load(config); err == nil || !strings.Contains(err.Error(), "BoughtFile") {
+		t.Fatalf("expected the shared bought file rejected, got %v", err)
+	}
+	// the same file written differently.
+	config.Bots[1].Config = filepath.Join(dir, "aggressive.yaml")
+	writeFile(t, config.Bots[1].Config, strings.Replace(testConfig, "BOUGHT", dir+"/./conservative.json", 1))
+	if _, err := Load(config); err == nil || !strings.Contains(err.Error(), "BoughtFile") {
+		t.Fatalf("expected the shared bought file rejected, got %v", err)
+	}
+	config.Bots[1].Name = "conservative"
+	if _, err := Load(config); err == nil || !strings.Contains(err.Error(), "duplicated") {
+		t.Fatalf("expected the duplicated name rejected, got %v", err)
+	}
+	config.Bots[1].Name = "bad/name"
+	if _, err := Load(config); err == nil {
+		t.Fatal("expected the invalid name rejected")
+	}
+}
@@ -0,0 +1,279 @@
+// Package telegram posts trade notifications to a telegram chat and handles
+// commands sent from it.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL the telegram bot api base url
+const DefaultAPIURL = "https://api.telegram.org"
+
+// Notifier posts the events of a trade to a chat, and handles the commands of the chat:
+// /positions, /sell SYMBOL, /pause, /resume and /pnl.
+type Notifier struct {
+	trade   *trade.Trade
+	token   string
+	chatID  int64
+	baseURL string
+	client  *http.Client
+	logger  logrus.FieldLogger
+
+	// pollTimeout the long polling timeout of getUpdates
+	pollTimeout time.Duration
+}
+
+// New returns a notifier of the trade configured by SystemOption.Telegram*, nil if TelegramToken is empty.
+func New(t *trade.Trade) *Notifier {
+	option := t.Option().SystemOption
+	if option.TelegramToken == "" {
+		return nil
+	}
+	baseURL := option.TelegramAPIURL
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Notifier{
+		trade:       t,
+		token:       option.TelegramToken,
+		chatID:      option.TelegramChatID,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		client:      &http.Client{Timeout: time.Minute},
+		logger:      t.Logger(),
+		pollTimeout: 30 * time.Second,
+	}
+}
+
+// Run handles commands and posts daily summaries until stopChan closed.
+func (n *Notifier) Run(stopChan chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopChan
+		cancel()
+	}()
+	go n.runSummary(ctx)
+	n.poll(ctx)
+}
+
+// OnEvent posts the event to the chat
+func (n *Notifier) OnEvent(e trade.Event) {
+	if err := n.send(context.Background(), eventText(e)); err != nil {
+		n.logger.WithError(err).Error("failed to send telegram message")
+	}
+}
+
+func eventText(e trade.Event) string {
+	switch e.Type {
+	case trade.EventBuy:
+		return fmt.Sprintf("🟢 bought %s\nquantity: %v\nprice: %v\ncost: %v",
+			e.Symbol, e.Position.ExecutedQuantity, e.Position.GetPrice(), e.Position.CummulativeQuoteQuantity)
+	case trade.EventSell:
+		text := fmt.Sprintf("🔴 sold %s\nreason: %s\nchange: %.2f%%", e.Symbol, e.Sell.Reason, e.Sell.PriceChange)
+		if p := e.Sell.PnL; p != nil {
+			text += fmt.Sprintf("\npnl: %.4f (%.2f%%)\ncommission: %.4f", p.PnL, p.PnLPercent, p.Commission)
+		}
+		return text
+	case trade.EventTrailing:
+		text := fmt.Sprintf("📈 %s take profit raised to %.2f%%", e.Symbol, e.Position.TakeProfit)
+		if e.Position.StopLoss != nil {
+			text += fmt.Sprintf(", stop loss to %.2f%%", *e.Position.StopLoss)
+		}
+		return text
+	case trade.EventError:
+		return fmt.Sprintf("⚠️ %s: %s", e.Symbol, e.Error)
+	}
+	return fmt.Sprintf("%s %s", e.Type, e.Symbol)
+}
+
+func (n *Notifier) runSummary(ctx context.Context) {
+	for {
+		now := time.Now().UTC()
+		next := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next.Sub(now)):
+		}
+		if err := n.send(ctx, summaryText(n.trade.PnL(), next.Add(-time.Hour))); err != nil {
+			n.logger.WithError(err).Error("failed to send telegram summary")
+		}
+	}
+}
+
+// summaryText the realised PnL of the day (UTC)
+func summaryText(report trade.PnLReport, day time.Time) string {
+	key := day.UTC().Format("2006-01-02")
+	text := "📊 " + key
+	s, ok := report.Days[key]
+	if !ok {
+		return text + "\nno trades"
+	}
+	return text + fmt.Sprintf("\ntrades: %d\nwins: %d\npnl: %.4f %s\ncommission: %.4f %s", s.Trades, s.Wins, s.PnL, report.Asset, s.Commission, report.Asset)
+}
+
+type update struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+func (n *Notifier) poll(ctx context.Context) {
+	var offset int64
+	for ctx.Err() == nil {
+		updates, err := n.getUpdates(ctx, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			n.logger.WithError(err).Error("failed to get telegram updates")
+			select {
+			case <-ctx.Done():
+			case <-time.After(3 * time.Second):
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil {
+				continue
+			}
+			if u.Message.Chat.ID != n.chatID {
+				n.logger.Warnf("ignored telegram message from chat %d", u.Message.Chat.ID)
+				continue
+			}
+			if err := n.send(ctx, n.handle(ctx, u.Message.Text)); err != nil {
+				n.logger.WithError(err).Error("failed to send telegram message")
+			}
+		}
+	}
+}
+
+// handle runs the command, returns the reply
+func (n *Notifier) handle(ctx context.Context, text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "unknown command"
+	}
+	// commands in groups are suffixed by the bot name: /pause@bot
+	command := strings.SplitN(fields[0], "@", 2)[0]
+	switch command {
+	case "/positions":
+		positions := n.trade.Positions(ctx)
+		if len(positions) == 0 {
+			return "no positions"
+		}
+		var b strings.Builder
+		for _, p := range positions {
+			fmt.Fprintf(&b, "%s %v @ %v, now %v, pnl %.4f (%.2f%%)\n",
+				p.Symbol, p.ExecutedQuantity, p.BuyPrice, p.LastPrice, p.UnrealisedPnL, p.UnrealisedPnLPercent)
+		}
+		return b.String()
+	case "/sell":
+		if len(fields) != 2 {
+			return "usage: /sell SYMBOL"
+		}
+		symbol := strings.ToUpper(fields[1])
+		if err := n.trade.SellNow(ctx, symbol); err != nil {
+			return err.Error()
+		}
+		return "selling " + symbol
+	case "/pause":
+		n.trade.Pause()
+		return "buying paused"
+	case "/resume":
+		n.trade.Resume()
+		return "buying resumed"
+	case "/pnl":
+		report := n.trade.PnL()
+		text := fmt.Sprintf("total trades: %d\nwins: %d\npnl: %.4f %s\ncommission: %.4f %s",
+			report.Total.Trades, report.Total.Wins, report.Total.PnL, report.Asset, report.Total.Commission, report.Asset)
+		// the native PnL of each quote, only worth it with more than one.
+		if len(report.Quotes) > 1 {
+			var quotes []string
+			for q := range report.Quotes {
+				quotes = append(quotes, q)
+			}
+			sort.Strings(quotes)
+			for _, q := range quotes {
+				text += fmt.Sprintf("\n%s: %.4f %s", q, report.Quotes[q].PnL, q)
+			}
+		}
+		return text + "\n\n" + summaryText(report, time.Now())
+	}
+	return "commands: /positions, /sell SYMBOL, /pause, /resume, /pnl"
+}
+
+type response struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+func (n *Notifier) call(ctx context.Context, method string, req *http.Request) (json.RawMessage, error) {
+	resp, err := n.client.Do(req.WithContext(ctx))
+	if err != nil {
+		// the url error quotes the url with the bot token, only its cause is returned.
+		if ue, ok := err.(*url.Error); ok {
+			err = ue.Err
+		}
+		return nil, fmt.Errorf("telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("telegram %s: %s", method, resp.Status)
+	}
+	if !r.OK {
+		return nil, fmt.Errorf("telegram %s: %s", method, r.Description)
+	}
+	return r.Result, nil
+}
+
+func (n *Notifier) url(method string) string {
+	return n.baseURL + "/bot" + n.token + "/" + method
+}
+
+func (n *Notifier) getUpdates(ctx context.Context, offset int64) ([]update, error) {
+	q := url.Values{}
+	q.Set("offset", strconv.FormatInt(offset, 10))
+	q.Set("timeout", strconv.Itoa(int(n.pollTimeout/time.Second)))
+	req, err := http.NewRequest(http.MethodGet, n.url("getUpdates")+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	result, err := n.call(ctx, "getUpdates", req)
+	if err != nil {
+		return nil, err
+	}
+	var updates []update
+	return updates, json.Unmarshal(result, &updates)
+}
+
+func (n *Notifier) send(ctx context.Context, text string) error {
+	body, _ := json.Marshal(map[string]interface{}{
+		"chat_id": n.chatID,
+		"text":    text,
+	})
+	req, err := http.NewRequest(http.MethodPost, n.url("sendMessage"), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	_, err = n.call(ctx, "sendMessage", req)
+	return err
+}
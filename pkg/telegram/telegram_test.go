@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// botAPI stands in for the telegram bot api, it delivers the updates once
+// and holds empty polls for a while like long polling.
+type botAPI struct {
+	mu      sync.Mutex
+	updates []string
+	sent    []string
+}
+
+func (b *botAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch r.URL.Path {
+	case "/bottoken/getUpdates":
+		if len(b.updates) == 0 {
+			b.mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			b.mu.Lock()
+		}
+		fmt.Fprintf(w, `{"ok":true,"result":[%s]}`, strings.Join(b.updates, ","))
+		b.updates = nil
+	case "/bottoken/sendMessage":
+		var msg struct {
+			ChatID int64  `json:"chat_id"`
+			Text   string `json:"text"`
+		}
+		json.NewDecoder(r.Body).Decode(&msg)
+		if msg.ChatID != 42 {
+			w.Write([]byte(`{"ok":false,"description":"chat not found"}`))
+			return
+		}
+		b.sent = append(b.sent, msg.Text)
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"ok":false,"description":"not found"}`))
+	}
+}
+
+func (b *botAPI) messages() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.sent...)
+}
+
+func newTestNotifier(t *testing.T, api *botAPI) (*Notifier, *trade.Trade) {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	tr := trade.NewTrade(
+		trade.WithBuyOption(trade.BuyOption{MainCoin: "USDT"}),
+		trade.WithSystemOption(trade.SystemOption{
+			TelegramToken:  "token",
+			TelegramChatID: 42,
+			TelegramAPIURL: server.URL,
+		}),
+	)
+	tr.SetExchange(trade.NewSimExchange(map[string]float64{"USDT": 100}))
+	n := New(tr)
+	n.pollTimeout = 0
+	return n, tr
+}
+
+func TestCommands(t *testing.T) {
+	api := &botAPI{updates: []string{
+		`{"update_id":1,"message":{"chat":{"id":7},"text":"/pause"}}`,
+		`{"update_id":2,"message":{"chat":{"id":42},"text":"/pause@mybot"}}`,
+		`{"update_id":3,"message":{"chat":{"id":42},"text":"/sell DOGEUSDT"}}`,
+	}}
+	n, tr := newTestNotifier(t, api)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go n.Run(stopCh)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(api.messages()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected replies, got %v", api.messages())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !tr.Paused() {
+		t.Fatal("expected buying paused")
+	}
+	sent := api.messages()
+	if sent[0] != "buying paused" || !strings.Contains(sent[1], "position not found") {
+		t.Fatalf("unexpected replies %v", sent)
+	}
+}
+
+func TestOnEvent(t *testing.T) {
+	api := new(botAPI)
+	n, _ := newTestNotifier(t, api)
+
+	n.OnEvent(trade.Event{
+		Type:   trade.EventSell,
+		Symbol: "DOGEUSDT",
+		Sell: &trade.SellBill{
+			Info:        &trade.BoughtInfo{Symbol: "DOGEUSDT"},
+			Reason:      trade.SellReasonForTakeProfit,
+			PriceChange: 2.5,
+			PnL:         &trade.TradePnL{PnL: 0.25, PnLPercent: 2.3},
+		},
+	})
+	sent := api.messages()
+	if len(sent) != 1 || !strings.Contains(sent[0], "sold DOGEUSDT") || !strings.Contains(sent[0], "pnl: 0.2500") {
+		t.Fatalf("unexpected messages %v", sent)
+	}
+}
+
+func TestErrorHidesToken(t *testing.T) {
+	n, _ := newTestNotifier(t, new(botAPI))
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	n.baseURL = server.URL
+
+	err := n.send(context.Background(), "hello")
+	if err == nil || strings.Contains(err.Error(), "token") {
+		t.Fatalf("expected an error without the token, got %v", err)
+	}
+}
+
+func TestSummaryText(t *testing.T) {
+	report := trade.PnLReport{Days: map[string]*trade.PnLSummary{
+		"2021-05-01": {Trades: 3, Wins: 2, PnL: 1.5},
+	}}
+	day := time.Date(2021, 5, 1, 23, 0, 0, 0, time.UTC)
+	if text := summaryText(report, day); !strings.Contains(text, "trades: 3") {
+		t.Fatalf("unexpected summary %s", text)
+	}
+	if text := summaryText(report, day.Add(24*time.Hour)); !strings.Contains(text, "no trades") {
+		t.Fatalf("unexpected summary %s", text)
+	}
+}
@@ -12,16 +12,24 @@ type SymbolPrice struct {
 	Symbol string
 	Price  float64
 	Time   time.Time
+
+	// Bid and Ask the best book prices, only known with PriceStream.
+	Bid float64
+	Ask float64
 }
 
-// GetSymbolPrice get the symbol price
+// GetSymbolPrice get the symbol price, from the price stream if it is alive, or else by REST polling.
 func (t *Trade) GetSymbolPrice(ctx context.Context, symbol string) map[string]*SymbolPrice {
-	var prs = make(map[string]*SymbolPrice)
-	svc := t.client.NewListPricesService()
-	if symbol != "" {
-		svc.Symbol(symbol)
+	if t.stream != nil && t.stream.fresh() {
+		return t.book.snapshot(symbol)
 	}
-	res, err := svc.Do(ctx)
+	return t.pollSymbolPrice(ctx, symbol)
+}
+
+// pollSymbolPrice get the symbol price by REST, and save them to the price book.
+func (t *Trade) pollSymbolPrice(ctx context.Context, symbol string) map[string]*SymbolPrice {
+	var prs = make(map[string]*SymbolPrice)
+	res, err := t.exchange.ListPrices(ctx, symbol)
 	if err != nil {
 		return prs
 	}
@@ -30,22 +38,29 @@ func (t *Trade) GetSymbolPrice(ctx context.Context, symbol string) map[string]*S
 		pr := &SymbolPrice{
 			Symbol: sp.Symbol,
 			Price:  price,
-			Time:   time.Now(),
+			Time:   t.now(),
 		}
 		prs[sp.Symbol] = pr
 	}
+
+	t.book.mu.Lock()
+	for _, pr := range prs {
+		t.book.setPrice(pr.Symbol, pr.Price, pr.Time)
+	}
+	t.book.mu.Unlock()
 	return prs
 }
 
 // Buy buy coin with market price with given number
 func (t *Trade) Buy(ctx context.Context, number float64, symbol string) (*binance.CreateOrderResponse, error) {
-	order, err := t.client.NewCreateOrderService().
-		Quantity(strconv.FormatFloat(number, 'g', -1, 64)).
-		Symbol(symbol).
-		Side(binance.SideTypeBuy).
-		Type(binance.OrderTypeMarket).
-		Do(ctx, binance.WithRecvWindow(50000))
+	order, err := t.exchange.CreateOrder(ctx, OrderRequest{
+		Symbol:   symbol,
+		Side:     binance.SideTypeBuy,
+		Type:     binance.OrderTypeMarket,
+		Quantity: number,
+	})
 	if err != nil {
+		t.filterFailed(err)
 		return nil, err
 	}
 	return order, nil
@@ -53,13 +68,14 @@ func (t *Trade) Buy(ctx context.Context, number float64, symbol string) (*binanc
 
 // Sell sell coin with market price with given number
 func (t *Trade) Sell(ctx context.Context, symbol string, number float64) (*binance.CreateOrderResponse, error) {
-	order, err := t.client.NewCreateOrderService().
-		Symbol(symbol).
-		Side(binance.SideTypeSell).
-		Type(binance.OrderTypeMarket).
-		Quantity(strconv.FormatFloat(number, 'g', -1, 64)).
-		Do(ctx, binance.WithRecvWindow(50000))
+	order, err := t.exchange.CreateOrder(ctx, OrderRequest{
+		Symbol:   symbol,
+		Side:     binance.SideTypeSell,
+		Type:     binance.OrderTypeMarket,
+		Quantity: number,
+	})
 	if err != nil {
+		t.filterFailed(err)
 		return nil, err
 	}
 	return order, nil
@@ -77,10 +93,7 @@ func (t *Trade) GetOrder(ctx context.Context, symbol string, id int64, retry int
 		retry = DefaultRetry
 	}
 	for i := 0; i < retry; i++ {
-		order, err = t.client.NewGetOrderService().
-			Symbol(symbol).
-			OrderID(id).
-			Do(ctx, binance.WithRecvWindow(50000))
+		order, err = t.exchange.GetOrder(ctx, symbol, id)
 		if err != nil {
 			continue
 		}
@@ -89,10 +102,20 @@ func (t *Trade) GetOrder(ctx context.Context, symbol string, id int64, retry int
 	return nil, err
 }
 
+// GetExchangeInfo get the exchange info from the cache, with only the given symbols if any.
 func (t *Trade) GetExchangeInfo(ctx context.Context, symbols ...string) (*binance.ExchangeInfo, error) {
-	info, err := t.client.NewExchangeInfoService().Do(ctx)
-	if err != nil {
-		return nil, err
+	for {
+		if err := t.loadExchangeInfo(ctx); err != nil {
+			return nil, err
+		}
+		// the cache may be reset since loaded, eg: the exchange is replaced.
+		if info := t.exchangeInfo.get(symbols); info != nil {
+			return info, nil
+		}
 	}
-	return info, nil
+}
+
+// GetBalances get the balances of the account
+func (t *Trade) GetBalances(ctx context.Context) ([]binance.Balance, error) {
+	return t.exchange.Balances(ctx)
 }
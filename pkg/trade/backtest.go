@@ -0,0 +1,306 @@
+package trade
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kline defines a candle of a symbol
+type Kline struct {
+	Symbol    string
+	OpenTime  time.Time
+	CloseTime time.Time
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+}
+
+// LoadKlineFile loads a binance kline csv file, eg: DOGEUSDT-1m-2021-05.csv,
+// the symbol is the file name before the first '-'.
+func LoadKlineFile(file string) ([]*Kline, error) {
+	fi, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fi.Close()
+
+	symbol := strings.ToUpper(strings.SplitN(filepath.Base(file), "-", 2)[0])
+	klines, err := ReadKlines(fi, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return klines, nil
+}
+
+// ReadKlines reads klines in binance csv format:
+// open time, open, high, low, close, volume, close time, ...
+// a header line is allowed.
+func ReadKlines(r io.Reader, symbol string) ([]*Kline, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var klines []*Kline
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 7 {
+			return nil, fmt.Errorf("line %d: expected at least 7 columns, got %d", line, len(record))
+		}
+		openTime, err := strconv.ParseInt(record[0], 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: invalid open time %q", line, record[0])
+		}
+		closeTime, err := strconv.ParseInt(record[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid close time %q", line, record[6])
+		}
+		var values [5]float64
+		for i := range values {
+			values[i], err = strconv.ParseFloat(record[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid number %q", line, record[i+1])
+			}
+		}
+		klines = append(klines, &Kline{
+			Symbol:    symbol,
+			OpenTime:  klineTime(openTime),
+			CloseTime: klineTime(closeTime),
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    values[4],
+		})
+	}
+	return klines, nil
+}
+
+// klineTime binance uses milliseconds, and microseconds since 2025.
+func klineTime(ts int64) time.Time {
+	if ts > 1e14 {
+		return time.Unix(0, ts*int64(time.Microsecond))
+	}
+	return time.Unix(0, ts*int64(time.Millisecond))
+}
+
+// BacktestOption defines the virtual account of a backtest
+type BacktestOption struct {
+	// Balance the MainCoin we start with
+	Balance float64
+
+	// Fee the commission rate of every fill, eg: 0.001
+	Fee float64
+
+	// Slippage the rate market orders are filled worse than the close price
+	Slippage float64
+}
+
+// BacktestTrade defines a closed position of a backtest
+type BacktestTrade struct {
+	Symbol    string
+	BuyTime   time.Time
+	SellTime  time.Time
+	BuyPrice  float64
+	SellPrice float64
+	Quantity  float64
+	Reason    SellReason
+	Fee       float64
+	PnL       float64
+}
+
+// BacktestReport defines the result of a backtest
+type BacktestReport struct {
+	Trades       []*BacktestTrade
+	Open         []*BoughtInfo
+	StartBalance float64
+	EndEquity    float64
+	TotalPnL     float64
+	Fees         float64
+	WinRate      float64
+
+	// MaxDrawdown the biggest fall of the equity from its peak, MaxDrawdownPercent in percent of the peak.
+	MaxDrawdown        float64
+	MaxDrawdownPercent float64
+}
+
+// Backtest replays klines through the same buy and TP/SL checks the live trading runs,
+// with a virtual clock moving to each close time and orders filled on a SimExchange.
+func Backtest(ctx context.Context, option Option, bt BacktestOption, klines []*Kline) (*BacktestReport, error) {
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("no klines to backtest")
+	}
+	mainCoin := option.BuyOption.MainCoin
+
+	sim := NewSimExchange(map[string]float64{mainCoin: bt.Balance})
+	sim.Fee = bt.Fee
+	sim.Slippage = bt.Slippage
+
+	// group klines by close time.
+	steps := make(map[time.Time][]*Kline)
+	listed := make(map[string]bool)
+	for _, k := range klines {
+		steps[k.CloseTime] = append(steps[k.CloseTime], k)
+		if !listed[k.Symbol] {
+			listed[k.Symbol] = true
+			sim.AddSymbol(SimSymbol{
+				Symbol:     k.Symbol,
+				BaseAsset:  strings.TrimSuffix(k.Symbol, mainCoin),
+				QuoteAsset: mainCoin,
+				StepSize:   simStepSize(k.Close),
+			})
+		}
+	}
+	var times []time.Time
+	for ti := range steps {
+		times = append(times, ti)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	// never touch the files of the live bot.
+	option.BuyOption.BoughtFile = ""
+	// klines have no book, entries are filled at the close price.
+	option.BuyOption.EntryMode = EntryMarket
+	// the OCO legs are closed out of the sell checks, TP/SL are polled to report every exit.
+	option.SellOption.OCO = false
+	option.SystemOption = SystemOption{Debug: option.SystemOption.Debug}
+
+	t := NewTrade(
+		WithBuyOption(option.BuyOption),
+		WithSellOption(option.SellOption),
+		WithSystemOption(option.SystemOption),
+	)
+	t.SetExchange(sim)
+	t.clock = sim.Now
+
+	var (
+		report = &BacktestReport{StartBalance: bt.Balance}
+		buys   = make(map[string]SimFill)
+		prices = make(map[string]float64)
+		peak   = bt.Balance
+		seen   int
+
+		nextBuyCheck  time.Time
+		nextSellCheck time.Time
+	)
+	for _, now := range times {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		sim.SetTime(now)
+		for _, k := range steps[now] {
+			sim.SetPrice(k.Symbol, k.Close)
+			prices[k.Symbol] = k.Close
+		}
+
+		if !now.Before(nextBuyCheck) {
+			t.buy(ctx, t.checkingPrice(ctx, option))
+			nextBuyCheck = now.Add(option.BuyOption.Interval)
+		}
+		fills := sim.Fills()
+		for _, f := range fills[seen:] {
+			if f.Side == binance.SideTypeBuy {
+				buys[f.Symbol] = f
+			}
+		}
+		seen = len(fills)
+
+		if !now.Before(nextSellCheck) {
+			if err := t.checkingTPSL(ctx, option); err != nil {
+				return nil, err
+			}
+			for len(t.sellChan) > 0 {
+				bill := <-t.sellChan
+				t.sell(ctx, bill)
+				fills := sim.Fills()
+				if len(fills) == seen {
+					continue
+				}
+				seen = len(fills)
+				sold, bought := fills[len(fills)-1], buys[bill.Info.Symbol]
+				report.Trades = append(report.Trades, &BacktestTrade{
+					Symbol:    bill.Info.Symbol,
+					BuyTime:   bought.Time,
+					SellTime:  sold.Time,
+					BuyPrice:  bought.Price,
+					SellPrice: sold.Price,
+					Quantity:  sold.Quantity,
+					Reason:    bill.Reason,
+					Fee:       bought.Fee + sold.Fee,
+					PnL:       sold.Quote - sold.Fee - (bought.Quote+bought.Fee)*sold.Quantity/bought.Quantity,
+				})
+			}
+			nextSellCheck = now.Add(option.SellOption.Interval)
+		}
+
+		equity := backtestEquity(sim, mainCoin, prices)
+		if equity > peak {
+			peak = equity
+		}
+		if drawdown := peak - equity; drawdown > report.MaxDrawdown {
+			report.MaxDrawdown = drawdown
+			report.MaxDrawdownPercent = drawdown / peak * 100
+		}
+		report.EndEquity = equity
+	}
+
+	var wins int
+	for _, trade := range report.Trades {
+		report.TotalPnL += trade.PnL
+		if trade.PnL > 0 {
+			wins++
+		}
+	}
+	for _, f := range sim.Fills() {
+		report.Fees += f.Fee
+	}
+	if len(report.Trades) > 0 {
+		report.WinRate = float64(wins) / float64(len(report.Trades)) * 100
+	}
+	for _, info := range t.getBoughtInfo() {
+		report.Open = append(report.Open, info)
+	}
+	return report, nil
+}
+
+// backtestEquity values all balances in mainCoin with the latest prices.
+func backtestEquity(sim *SimExchange, mainCoin string, prices map[string]float64) float64 {
+	equity := sim.Balance(mainCoin)
+	for symbol, price := range prices {
+		equity += sim.Balance(strings.TrimSuffix(symbol, mainCoin)) * price
+	}
+	return equity
+}
+
+// simStepSize guesses a binance like LOT_SIZE step, worth between 0.1 and 1 MainCoin.
+func simStepSize(price float64) string {
+	if price <= 0 {
+		return ""
+	}
+	step := math.Pow10(-int(math.Floor(math.Log10(price))) - 1)
+	if step > 1 {
+		step = 1
+	}
+	return strconv.FormatFloat(step, 'f', 8, 64)
+}
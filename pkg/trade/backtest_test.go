@@ -0,0 +1,70 @@
+package trade
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKlines = `open_time,open,high,low,close,volume,close_time,quote_volume,count,taker_buy_volume,taker_buy_quote_volume,ignore
+1620000000000,1.00,1.00,1.00,1.00,100,1620000059999,100,1,0,0,0
+1620000060000,1.00,1.02,1.00,1.02,100,1620000119999,100,1,0,0,0
+1620000120000,1.02,1.05,1.02,1.05,100,1620000179999,100,1,0,0,0
+1620000180000,1.05,1.05,1.00,1.00,100,1620000239999,100,1,0,0,0
+1620000240000,1.00,1.02,1.00,1.02,100,1620000299999,100,1,0,0,0
+1620000300000,1.02,1.02,0.98,0.98,100,1620000359999,100,1,0,0,0
+`
+
+func TestBacktest(t *testing.T) {
+	klines, err := ReadKlines(strings.NewReader(testKlines), "DOGEUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(klines) != 6 {
+		t.Fatalf("expected 6 klines, got %d", len(klines))
+	}
+
+	up := 1.0
+	option := Option{
+		BuyOption: BuyOption{
+			Interval:      time.Minute,
+			PriceUpChange: &up,
+			MaxBuy:        1,
+			MoneyPerOrder: 11,
+			MainCoin:      "USDT",
+			WhiteList:     []string{"DOGE"},
+		},
+		SellOption: testSellOption,
+	}
+	report, err := Backtest(context.Background(), option, BacktestOption{Balance: 100, Fee: 0.001}, klines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Trades) != 2 {
+		t.Fatalf("expected 2 trades, got %d", len(report.Trades))
+	}
+	if report.Trades[0].Reason != SellReasonForTakeProfit || report.Trades[1].Reason != SellReasonForForceStopLoss {
+		t.Fatalf("unexpected reasons %s, %s", report.Trades[0].Reason, report.Trades[1].Reason)
+	}
+	if report.Trades[0].SellTime.Sub(report.Trades[0].BuyTime) != time.Minute {
+		t.Fatalf("expected to sell a minute after buying, got %s", report.Trades[0].SellTime.Sub(report.Trades[0].BuyTime))
+	}
+	if report.WinRate != 50 {
+		t.Fatalf("expected win rate 50, got %f", report.WinRate)
+	}
+	if report.MaxDrawdown <= 0 || report.Fees <= 0 {
+		t.Fatalf("unexpected drawdown=%f fees=%f", report.MaxDrawdown, report.Fees)
+	}
+
+	// OCO is polled in the backtest, its exits are reported too.
+	option.SellOption.OCO = true
+	report, err = Backtest(context.Background(), option, BacktestOption{Balance: 100, Fee: 0.001}, klines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Trades) != 2 || report.WinRate != 50 {
+		t.Fatalf("expected 2 trades with OCO, got %d win rate %f", len(report.Trades), report.WinRate)
+	}
+}
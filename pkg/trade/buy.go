@@ -2,6 +2,7 @@ package trade
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/adshao/go-binance/v2"
 	"math"
@@ -22,6 +23,22 @@ type BoughtInfo struct {
 	ExecutedQuantity         float64   `json:"executedQuantity"`
 	CummulativeQuoteQuantity float64   `json:"cummulativeQuoteQuantity"`
 	LotSize                  int       `json:"lotSize"`
+
+	// Commission the commission of the buy order in QuoteAsset, converted at fill time.
+	Commission float64 `json:"commission"`
+	BaseAsset  string  `json:"baseAsset"`
+	QuoteAsset string  `json:"quoteAsset"`
+
+	// BaseCommission the coins paid as Commission, they are bought in CummulativeQuoteQuantity too.
+	BaseCommission float64 `json:"baseCommission,omitempty"`
+
+	// PriceSize the decimals of the price, like LotSize of the quantity.
+	PriceSize int `json:"priceSize"`
+
+	// OrderListID the exchange-side OCO protecting the position, 0 if TP/SL are only polled.
+	OrderListID     int64 `json:"orderListId,omitempty"`
+	OCOLimitOrderID int64 `json:"ocoLimitOrderId,omitempty"`
+	OCOStopOrderID  int64 `json:"ocoStopOrderId,omitempty"`
 }
 
 func (b *BoughtInfo) GetPrice() float64 {
@@ -31,131 +48,233 @@ func (b *BoughtInfo) GetPrice() float64 {
 	return math.MaxFloat64
 }
 
+// assets returns the base and quote asset, positions saved before they are recorded
+// use the quote matching the symbol, or the MainCoin.
+func (b *BoughtInfo) assets(option BuyOption) (string, string) {
+	if b.QuoteAsset != "" {
+		return b.BaseAsset, b.QuoteAsset
+	}
+	quote := option.MainCoin
+	if q, ok := option.quoteOf(b.Symbol); ok {
+		quote = q.Coin
+	}
+	return strings.TrimSuffix(b.Symbol, quote), quote
+}
+
 func (t *Trade) runBuy(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case change := <-t.buyChan:
-			option := t.Option()
-			for _, c := range change {
-				t.boughtMutex.Lock()
-				count := len(t.boughtInfo)
-				t.boughtMutex.Unlock()
-				if count >= option.BuyOption.MaxBuy {
-					continue
-				}
-				if order, err := t.buySymbol(ctx, c.symbol, c.lastPrice); err != nil {
-					t.logger.WithError(err).Errorf("failed to buy symbol=%s price=%f tp=%f", c.symbol, c.lastPrice, c.change)
-				} else {
-					price, _ := strconv.ParseFloat(order.CummulativeQuoteQuantity, 64)
-					number, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
-					var (
-						stopLoss      *float64
-						forceStopLoss *float64
-					)
-					if option.SellOption.StopLoss != 0 {
-						v := -1 * option.SellOption.StopLoss
-						stopLoss = &v
-					}
-					if option.SellOption.ForceStopLoss != 0 {
-						v := -1 * option.SellOption.ForceStopLoss
-						forceStopLoss = &v
-					}
-					info := &BoughtInfo{
-						Symbol:                   c.symbol,
-						OrderId:                  order.OrderID,
-						Time:                     time.Now(),
-						StopLoss:                 stopLoss,
-						ForceStopLoss:            forceStopLoss,
-						TakeProfit:               option.SellOption.TakeProfit,
-						Volume:                   order.Number,
-						ExecutedQuantity:         number,
-						CummulativeQuoteQuantity: price,
-						LotSize:                  order.LotSize,
-					}
-					t.boughtMutex.Lock()
-					t.boughtInfo[c.symbol] = info
-					t.boughtMutex.Unlock()
-				}
+			start := time.Now()
+			t.buy(ctx, change)
+			t.observeLoop("buy", start)
+		}
+	}
+}
+
+// buy buys the signals until MaxBuy of their quote is reached.
+func (t *Trade) buy(ctx context.Context, signals []Signal) {
+	for _, c := range signals {
+		if _, err := t.buyPosition(ctx, c.Symbol, c.Price, nil); err != nil {
+			if err != errBought && err != errMaxBuy {
+				t.logger.WithError(err).Errorf("failed to buy symbol=%s price=%f score=%f", c.Symbol, c.Price, c.Score)
+				t.emitError(c.Symbol, err)
 			}
-			t.save()
+			continue
 		}
+		buysTotal.WithLabelValues(t.metricsLabel(), buyReasonStrategy).Inc()
 	}
+	t.save()
 }
 
-type Order struct {
-	*binance.Order
-	Number  float64
-	LotSize int
+var (
+	errMaxBuy = errors.New("already bought MaxBuy coins")
+	errBought = errors.New("already bought")
+)
+
+// positionOverride replaces the options of a single position, zero values keep the options.
+type positionOverride struct {
+	// Money the quote to spend instead of MoneyPerOrder
+	Money float64
+
+	// TakeProfit and StopLoss in percent, both positive.
+	TakeProfit *float64
+	StopLoss   *float64
 }
 
-// buySymbol buy a symbol
-func (t *Trade) buySymbol(ctx context.Context, symbol string, lastPrice float64) (*Order, error) {
+// buyPosition buys the symbol and records the position, it checks MaxBuy of the quote and the positions we hold.
+func (t *Trade) buyPosition(ctx context.Context, symbol string, nowPrice float64, override *positionOverride) (*BoughtInfo, error) {
+	ctx = WithPriority(ctx, PriorityBuy)
+	t.buyMutex.Lock()
+	defer t.buyMutex.Unlock()
+
 	option := t.Option()
-	info, err := t.GetExchangeInfo(ctx, symbol)
-	if err != nil {
-		return nil, err
+	quote, ok := t.symbolQuote(symbol)
+	if !ok {
+		return nil, fmt.Errorf("%s is not quoted in any of the quotes", symbol)
 	}
-
-	var symbolInfo binance.Symbol
-	for _, s := range info.Symbols {
-		if s.Symbol == symbol {
-			symbolInfo = s
-			break
+	var count int
+	t.boughtMutex.Lock()
+	for _, info := range t.boughtInfo {
+		if _, q := info.assets(option.BuyOption); q == quote.Coin {
+			count++
 		}
 	}
-
-	if symbolInfo.Symbol == "" {
-		return nil, fmt.Errorf("symbol not found: %s", symbol)
+	_, bought := t.boughtInfo[symbol]
+	t.boughtMutex.Unlock()
+	if count >= quote.MaxBuy {
+		return nil, errMaxBuy
 	}
-
-	if len(symbolInfo.Filters) < 3 {
-		return nil, fmt.Errorf("not found stepSize in symbol: %s", symbol)
+	if bought {
+		return nil, errBought
 	}
 
-	stepSize, ok := symbolInfo.Filters[2]["stepSize"]
-	if !ok {
-		return nil, fmt.Errorf("not found stepSize in symbol: %s", symbol)
+	money := quote.MoneyPerOrder
+	if override != nil && override.Money != 0 {
+		money = override.Money
 	}
-	stepSizeString, ok := stepSize.(string)
-	if !ok {
-		return nil, fmt.Errorf("not found stepSize in symbol: %s", symbol)
+	order, err := t.buySymbol(ctx, symbol, nowPrice, money)
+	if err != nil {
+		return nil, err
 	}
-	lotSize := strings.Index(stepSizeString, "1") - 1
-	if lotSize < 0 {
-		lotSize = 0
+	price, _ := strconv.ParseFloat(order.CummulativeQuoteQuantity, 64)
+	number, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+	var (
+		stopLoss      *float64
+		forceStopLoss *float64
+	)
+	takeProfit := option.SellOption.TakeProfit
+	if option.SellOption.StopLoss != 0 {
+		v := -1 * option.SellOption.StopLoss
+		stopLoss = &v
 	}
+	if override != nil {
+		if override.TakeProfit != nil {
+			takeProfit = *override.TakeProfit
+		}
+		if override.StopLoss != nil {
+			v := -1 * *override.StopLoss
+			stopLoss = &v
+		}
+	}
+	if option.SellOption.ForceStopLoss != 0 {
+		v := -1 * option.SellOption.ForceStopLoss
+		forceStopLoss = &v
+	}
+	info := &BoughtInfo{
+		Symbol:                   symbol,
+		OrderId:                  order.OrderID,
+		Time:                     t.now(),
+		StopLoss:                 stopLoss,
+		ForceStopLoss:            forceStopLoss,
+		TakeProfit:               takeProfit,
+		Volume:                   order.Number,
+		ExecutedQuantity:         number,
+		CummulativeQuoteQuantity: price,
+		LotSize:                  order.LotSize,
+		BaseAsset:                order.BaseAsset,
+		QuoteAsset:               order.QuoteAsset,
+		PriceSize:                order.PriceSize,
+	}
+	if order.Response != nil {
+		fills := t.summarizeFills(ctx, order.Response, order.BaseAsset, order.QuoteAsset)
+		info.Commission = fills.Commission
+		info.BaseCommission = fills.BaseCommission
+		// the commission paid in coins can not be sold.
+		if fills.BaseCommission > 0 {
+			info.Volume = FloatTrunc(number-fills.BaseCommission, order.LotSize)
+		}
+	}
+	if option.SellOption.OCO {
+		if err := t.placeOCO(ctx, info); err != nil {
+			t.logger.WithError(err).Errorf("failed to place OCO of %s, TP/SL are polled", symbol)
+			t.emitError(symbol, err)
+		}
+	}
+	t.boughtMutex.Lock()
+	t.boughtInfo[symbol] = info
+	t.boughtMutex.Unlock()
 
-	// calculate number:  use total money / current price.
-	number := option.BuyOption.MoneyPerOrder / lastPrice
+	t.record(&JournalEntry{
+		Type:       JournalBuy,
+		Symbol:     symbol,
+		Time:       info.Time,
+		Commission: info.Commission,
+	}, order.Response)
+	t.updatePositionMetrics()
+	c := *info
+	t.emit(Event{Type: EventBuy, Symbol: symbol, Position: &c})
+	return info, nil
+}
 
-	if lotSize == 0 {
-		number = float64(int(number))
-	} else {
-		number = FloatTrunc(number, lotSize)
-	}
+type Order struct {
+	*binance.Order
+	Number    float64
+	LotSize   int
+	PriceSize int
 
-	//t.BeforeBuy(symbol, number, lastPrice)
+	BaseAsset  string
+	QuoteAsset string
 
-	// buy.
-	resp, err := t.Buy(ctx, number, symbol)
+	// Response the response of creating the order, with fills.
+	Response *binance.CreateOrderResponse
+}
+
+// buySymbol buy a symbol
+func (t *Trade) buySymbol(ctx context.Context, symbol string, lastPrice float64, money float64) (*Order, error) {
+	rules, err := t.SymbolRules(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
 
-	// query order.
-	order, err := t.GetOrder(ctx, symbol, resp.OrderID, math.MaxInt64)
-	if err != nil {
-		return nil, err
+	// calculate number:  use total money / current price.
+	number := rules.RoundQuantity(money/lastPrice, true)
+
+	//t.BeforeBuy(symbol, number, lastPrice)
+
+	var (
+		order *binance.Order
+		resp  *binance.CreateOrderResponse
+	)
+	switch t.Option().BuyOption.EntryMode {
+	case "", EntryMarket:
+		if err := rules.Validate(number, lastPrice, true); err != nil {
+			return nil, err
+		}
+
+		// buy.
+		resp, err = t.Buy(ctx, number, symbol)
+		if err != nil {
+			return nil, err
+		}
+
+		// query order.
+		order, err = t.GetOrder(ctx, symbol, resp.OrderID, math.MaxInt64)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		order, resp, err = t.limitBuy(ctx, rules, money)
+		if err != nil {
+			return nil, err
+		}
+		// the orders may be partially filled.
+		executed, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+		number = FloatTrunc(executed, rules.LotSize)
 	}
 
 	if t.AfterBuy != nil {
 		go t.AfterBuy(order)
 	}
 	return &Order{
-		Order:   order,
-		Number:  number,
-		LotSize: lotSize,
+		Order:      order,
+		Number:     number,
+		LotSize:    rules.LotSize,
+		PriceSize:  rules.PriceSize,
+		Response:   resp,
+		BaseAsset:  rules.BaseAsset,
+		QuoteAsset: rules.QuoteAsset,
 	}, nil
 }
@@ -307,13 +307,22 @@ var (
 )
 
 var (
+	// blackList the default BuyOption.Exclude of the universe: fiat pairs and stablecoins,
+	// leveraged tokens are told by their pairs, see leveragedToken.
 	blackList = []string{
-		"EURUSDT",
-		"GBPUSDT",
-		"JPYUSDT",
-		"USDUSDT",
-		"DOWN",
+		"EUR",
+		"GBP",
+		"JPY",
+		"AUD",
 		"SHIB",
-		"UP",
+		"USDC",
+		"BUSD",
+		"TUSD",
+		"USDP",
+		"PAX",
+		"USDS",
+		"USDSB",
+		"DAI",
+		"FDUSD",
 	}
 )
@@ -0,0 +1,408 @@
+package trade
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ghodss/yaml"
+	"io/ioutil"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultConfigCheckInterval how often the config file is checked for changes
+const DefaultConfigCheckInterval = 5 * time.Second
+
+// LoadOption reads the option from a yaml config file and validates it.
+// Durations are strings like "500ms", "5s" or "1m", bare numbers are rejected.
+func LoadOption(file string) (*Option, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	if problems := bareDurations(data); len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+	var opt = new(Option)
+	if err := json.Unmarshal(data, opt); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+	return opt, nil
+}
+
+// bareDurations reports the duration fields of a config given as bare numbers. Before durations took units
+// the numbers were seconds, eg: EntryTimeout: 10, they would silently be read as nanoseconds now.
+func bareDurations(data []byte) []string {
+	var sections map[string]interface{}
+	if err := json.Unmarshal(data, &sections); err != nil {
+		// reported by decoding the option.
+		return nil
+	}
+	var (
+		problems     []string
+		typ          = reflect.TypeOf(Option{})
+		durationType = reflect.TypeOf(time.Duration(0))
+	)
+	for i := 0; i < typ.NumField(); i++ {
+		section := typ.Field(i)
+		fields, _ := sections[section.Name].(map[string]interface{})
+		for j := 0; j < section.Type.NumField(); j++ {
+			f := section.Type.Field(j)
+			if f.Type != durationType {
+				continue
+			}
+			if n, ok := fields[f.Name].(float64); ok && n != 0 {
+				problems = append(problems, fmt.Sprintf("%s.%s: %v has no unit, use a duration like \"%vs\"", section.Name, f.Name, n, n))
+			}
+		}
+	}
+	return problems
+}
+
+// duration decodes a time.Duration from a string like "5s", or a number of nanoseconds
+// as the journal stores the option snapshots.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, use a duration like \"5s\" or \"1m\"", s)
+		}
+		*d = duration(v)
+		return nil
+	}
+	var n float64
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid duration %s, use a duration like \"5s\" or \"1m\"", data)
+	}
+	*d = duration(n)
+	return nil
+}
+
+func (o *BuyOption) UnmarshalJSON(data []byte) error {
+	type plain BuyOption
+	v := struct {
+		*plain
+		Interval              *duration
+		SameCoinBlockDuration *duration
+		EntryTimeout          *duration
+	}{plain: (*plain)(o)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	setDuration(&o.Interval, v.Interval)
+	setDuration(&o.SameCoinBlockDuration, v.SameCoinBlockDuration)
+	setDuration(&o.EntryTimeout, v.EntryTimeout)
+	return nil
+}
+
+func (o *SellOption) UnmarshalJSON(data []byte) error {
+	type plain SellOption
+	v := struct {
+		*plain
+		Interval         *duration
+		StopLossDuration *duration
+	}{plain: (*plain)(o)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	setDuration(&o.Interval, v.Interval)
+	setDuration(&o.StopLossDuration, v.StopLossDuration)
+	return nil
+}
+
+func (o *SystemOption) UnmarshalJSON(data []byte) error {
+	type plain SystemOption
+	v := struct {
+		*plain
+		PriceStreamStaleAfter *duration
+		ExchangeInfoInterval  *duration
+	}{plain: (*plain)(o)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	setDuration(&o.PriceStreamStaleAfter, v.PriceStreamStaleAfter)
+	setDuration(&o.ExchangeInfoInterval, v.ExchangeInfoInterval)
+	return nil
+}
+
+func setDuration(dst *time.Duration, d *duration) {
+	if d != nil {
+		*dst = time.Duration(*d)
+	}
+}
+
+// ConfigError lists all the problems found in an option.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// configProblems collects the problems of an option.
+type configProblems []string
+
+func (p *configProblems) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		*p = append(*p, fmt.Sprintf(format, args...))
+	}
+}
+
+// checkInterval checks a polling interval is neither too short to be sane nor longer than a day.
+func (p *configProblems) checkInterval(name string, d time.Duration) {
+	p.check(d >= 100*time.Millisecond && d <= 24*time.Hour, "%s: %s must be in [100ms, 24h], use a duration like \"1s\"", name, d)
+}
+
+func (p *configProblems) err() error {
+	if len(*p) == 0 {
+		return nil
+	}
+	return &ConfigError{Problems: *p}
+}
+
+// Validate checks the ranges and the rules between fields of the option, it reports all the problems at once.
+func (o *Option) Validate() error {
+	var p configProblems
+
+	buy := o.BuyOption
+	p.checkInterval("BuyOption.Interval", buy.Interval)
+	p.check(buy.MainCoin != "", "BuyOption.MainCoin is required")
+	if len(buy.Quotes) == 0 {
+		p.check(buy.MaxBuy >= 0, "BuyOption.MaxBuy: %d must not be negative", buy.MaxBuy)
+		p.check(buy.MoneyPerOrder > 0, "BuyOption.MoneyPerOrder: %v must be positive", buy.MoneyPerOrder)
+	}
+	seen := make(map[string]bool)
+	for i, q := range buy.Quotes {
+		p.check(q.Coin != "", "BuyOption.Quotes[%d].Coin is required", i)
+		p.check(!seen[q.Coin], "BuyOption.Quotes[%d].Coin: %s is duplicated", i, q.Coin)
+		p.check(q.MaxBuy >= 0, "BuyOption.Quotes[%d].MaxBuy: %d must not be negative", i, q.MaxBuy)
+		p.check(q.MoneyPerOrder > 0, "BuyOption.Quotes[%d].MoneyPerOrder: %v must be positive", i, q.MoneyPerOrder)
+		p.check(q.SignalMaxSize >= 0, "BuyOption.Quotes[%d].SignalMaxSize: %v must not be negative", i, q.SignalMaxSize)
+		seen[q.Coin] = true
+	}
+	p.check(buy.PriceUpChange == nil || *buy.PriceUpChange >= 0, "BuyOption.PriceUpChange must not be negative")
+	p.check(buy.SameCoinBlockDuration >= 0, "BuyOption.SameCoinBlockDuration: %s must not be negative", buy.SameCoinBlockDuration)
+	p.check(buy.SignalMaxSize >= 0, "BuyOption.SignalMaxSize: %v must not be negative", buy.SignalMaxSize)
+	switch buy.EntryMode {
+	case "", EntryMarket, EntryLimit, EntryLimitMaker:
+	default:
+		p.check(false, "BuyOption.EntryMode: %q must be one of %s, %s, %s", buy.EntryMode, EntryMarket, EntryLimit, EntryLimitMaker)
+	}
+	p.check(buy.EntryTimeout >= 0, "BuyOption.EntryTimeout: %s must not be negative", buy.EntryTimeout)
+	p.check(buy.EntryReprices >= 0, "BuyOption.EntryReprices: %d must not be negative", buy.EntryReprices)
+	for _, pattern := range append(append([]string{}, buy.Include...), buy.Exclude...) {
+		_, err := path.Match(pattern, "")
+		p.check(err == nil, "BuyOption.Include/Exclude: invalid pattern %q", pattern)
+	}
+	p.check(buy.Universe || len(buy.WhiteList) > 0, "BuyOption.WhiteList is required without Universe")
+	if _, err := NewStrategy(buy.Strategy, *o); err != nil {
+		p.check(false, "BuyOption.Strategy: %s", err)
+	}
+
+	sell := o.SellOption
+	p.checkInterval("SellOption.Interval", sell.Interval)
+	p.check(sell.TakeProfit >= 0, "SellOption.TakeProfit: %v must not be negative", sell.TakeProfit)
+	p.check(sell.StopLoss >= 0 && sell.StopLoss < 100, "SellOption.StopLoss: %v must be in [0, 100)", sell.StopLoss)
+	p.check(sell.ForceStopLoss >= 0 && sell.ForceStopLoss < 100, "SellOption.ForceStopLoss: %v must be in [0, 100)", sell.ForceStopLoss)
+	p.check(sell.ForceStopLoss == 0 || sell.StopLoss == 0 || sell.ForceStopLoss >= sell.StopLoss,
+		"SellOption.ForceStopLoss: %v must not be less than StopLoss %v", sell.ForceStopLoss, sell.StopLoss)
+	p.check(sell.StopLossDuration >= 0, "SellOption.StopLossDuration: %s must not be negative", sell.StopLossDuration)
+	p.check(sell.TrailingTakeProfit >= 0, "SellOption.TrailingTakeProfit: %v must not be negative", sell.TrailingTakeProfit)
+	p.check(sell.TrailingStopLoss >= 0, "SellOption.TrailingStopLoss: %v must not be negative", sell.TrailingStopLoss)
+	p.check(!sell.OCO || sell.StopLoss > 0 || sell.ForceStopLoss > 0, "SellOption.OCO needs StopLoss or ForceStopLoss")
+	p.check(!sell.OCO || sell.StopLossDuration == 0, "SellOption.OCO: the stop leg triggers at once, StopLossDuration %s is not supported", sell.StopLossDuration)
+	p.check(sell.OCOStopLimitGap >= 0 && sell.OCOStopLimitGap < 100, "SellOption.OCOStopLimitGap: %v must be in [0, 100)", sell.OCOStopLimitGap)
+
+	system := o.SystemOption
+	p.check(system.PriceStreamStaleAfter >= 0, "SystemOption.PriceStreamStaleAfter: %s must not be negative", system.PriceStreamStaleAfter)
+	p.check(system.ExchangeInfoInterval == 0 || system.ExchangeInfoInterval >= time.Minute,
+		"SystemOption.ExchangeInfoInterval: %s must be at least 1m", system.ExchangeInfoInterval)
+	p.check(system.PaperBalance >= 0, "SystemOption.PaperBalance: %v must not be negative", system.PaperBalance)
+	p.check(system.PaperFee >= 0 && system.PaperFee < 1, "SystemOption.PaperFee: %v must be in [0, 1)", system.PaperFee)
+	p.check(system.PaperSlippage >= 0 && system.PaperSlippage < 1, "SystemOption.PaperSlippage: %v must be in [0, 1)", system.PaperSlippage)
+	p.check(system.TelegramToken == "" || system.TelegramChatID != 0, "SystemOption.TelegramChatID is required with TelegramToken")
+	p.check(system.WeightLimit >= 0, "SystemOption.WeightLimit: %d must not be negative", system.WeightLimit)
+	p.check(system.OrderLimit >= 0, "SystemOption.OrderLimit: %d must not be negative", system.OrderLimit)
+
+	for i, w := range system.Webhooks {
+		p.check(w.URL != "", "SystemOption.Webhooks[%d].URL is required", i)
+		p.check(w.MaxRetries >= 0, "SystemOption.Webhooks[%d].MaxRetries: %d must not be negative", i, w.MaxRetries)
+	}
+	return p.err()
+}
+
+// validateMinNotional checks the MoneyPerOrder of each quote buys at least the min notional of the white listed symbols,
+// with the cached exchange info. Nothing is checked if it is not loaded, the universe drops such symbols instead.
+func (t *Trade) validateMinNotional(option Option) error {
+	if option.BuyOption.Universe {
+		return nil
+	}
+	c := t.exchangeInfo
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var p configProblems
+	for i, q := range option.BuyOption.quotes() {
+		field := "BuyOption.MoneyPerOrder"
+		if len(option.BuyOption.Quotes) > 0 {
+			field = fmt.Sprintf("BuyOption.Quotes[%d].MoneyPerOrder", i)
+		}
+		for _, coin := range option.BuyOption.WhiteList {
+			symbol := coin + q.Coin
+			if rules, ok := c.rules[symbol]; ok {
+				p.check(q.MoneyPerOrder >= rules.MinNotional,
+					"%s: %v is less than the min notional %v of %s", field, q.MoneyPerOrder, rules.MinNotional, symbol)
+			}
+		}
+	}
+	return p.err()
+}
+
+// Reload swaps the buy and sell options at runtime, open positions are kept with their TP/SL.
+// The system option, MainCoin and BoughtFile need a restart. It returns the changes.
+func (t *Trade) Reload(option Option) ([]string, error) {
+	if err := option.Validate(); err != nil {
+		return nil, err
+	}
+	if err := t.validateMinNotional(option); err != nil {
+		return nil, err
+	}
+
+	t.reloadMutex.Lock()
+	defer t.reloadMutex.Unlock()
+
+	old := t.Option()
+	if option.BuyOption.MainCoin != old.BuyOption.MainCoin {
+		return nil, fmt.Errorf("BuyOption.MainCoin can not be changed without a restart")
+	}
+	if option.BuyOption.BoughtFile != old.BuyOption.BoughtFile {
+		return nil, fmt.Errorf("BuyOption.BoughtFile can not be changed without a restart")
+	}
+	if !reflect.DeepEqual(option.SystemOption, old.SystemOption) {
+		t.logger.Warn("SystemOption changed, it is applied on the next start")
+	}
+	option.SystemOption = old.SystemOption
+
+	changes := append(optionDiff("BuyOption", old.BuyOption, option.BuyOption),
+		optionDiff("SellOption", old.SellOption, option.SellOption)...)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	var strategy Strategy
+	if !reflect.DeepEqual(option.BuyOption, old.BuyOption) {
+		var err error
+		if strategy, err = NewStrategy(option.BuyOption.Strategy, option); err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.Lock()
+	t.option = option
+	if strategy != nil {
+		t.strategy = strategy
+	}
+	t.mu.Unlock()
+
+	t.rebuildUniverse()
+	select {
+	case t.reloadChan <- struct{}{}:
+	default:
+	}
+	for _, c := range changes {
+		t.logger.Infof("option reloaded: %s", c)
+	}
+	return changes, nil
+}
+
+// ReloadConfig reloads the config file watched by WatchConfig.
+func (t *Trade) ReloadConfig() ([]string, error) {
+	t.mu.RLock()
+	file := t.configFile
+	t.mu.RUnlock()
+	if file == "" {
+		return nil, errors.New("no config file")
+	}
+	option, err := LoadOption(file)
+	if err != nil {
+		return nil, err
+	}
+	return t.Reload(*option)
+}
+
+// WatchConfig reloads the config file when its content changes, until stopChan is closed.
+func (t *Trade) WatchConfig(stopChan chan struct{}, file string) {
+	t.mu.Lock()
+	t.configFile = file
+	t.mu.Unlock()
+
+	sum := func() [sha256.Size]byte {
+		data, _ := ioutil.ReadFile(file)
+		return sha256.Sum256(data)
+	}
+	last := sum()
+	ticker := time.NewTicker(DefaultConfigCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			current := sum()
+			if current == last {
+				continue
+			}
+			last = current
+			if _, err := t.ReloadConfig(); err != nil {
+				t.logger.WithError(err).Errorf("failed to reload %s, the option is kept", file)
+			}
+		}
+	}
+}
+
+// optionDiff lists the fields changed between two options as "prefix.Field: old -> new".
+func optionDiff(prefix string, old, new interface{}) []string {
+	var (
+		changes []string
+		ov      = reflect.ValueOf(old)
+		nv      = reflect.ValueOf(new)
+	)
+	for i := 0; i < ov.NumField(); i++ {
+		a, b := ov.Field(i).Interface(), nv.Field(i).Interface()
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s.%s: %v -> %v", prefix, ov.Type().Field(i).Name, diffValue(a), diffValue(b)))
+	}
+	return changes
+}
+
+func diffValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "nil"
+		}
+		return rv.Elem().Interface()
+	}
+	return v
+}
@@ -0,0 +1,129 @@
+package trade
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+BuyOption:
+  Interval: 1s
+  MaxBuy: 2
+  MoneyPerOrder: 11
+  MainCoin: USDT
+  WhiteList: [DOGE]
+  SameCoinBlockDuration: 1m
+SellOption:
+  Interval: 1s
+  TakeProfit: 2
+  StopLoss: 1.5
+`
+
+func TestLoadOption(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(file, []byte(testConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	option, err := LoadOption(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if option.BuyOption.Interval != time.Second || option.BuyOption.SameCoinBlockDuration != time.Minute || option.SellOption.StopLoss != 1.5 {
+		t.Fatalf("unexpected option %+v", option)
+	}
+
+	invalid := strings.NewReplacer(
+		"MaxBuy: 2", "MaxBuy: -1",
+		"Interval: 1s\n  TakeProfit", "Interval: 1000h\n  TakeProfit",
+		"StopLoss: 1.5", "StopLoss: 1.5\n  ForceStopLoss: 1",
+	).Replace(testConfig)
+	if err := ioutil.WriteFile(file, []byte(invalid), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadOption(file)
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 3 {
+		t.Fatalf("expected 3 problems reported at once, got %v", err)
+	}
+
+	if err := ioutil.WriteFile(file, []byte(strings.Replace(testConfig, "Interval: 1s", "Interval: fast", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOption(file); err == nil || !strings.Contains(err.Error(), `invalid duration "fast"`) {
+		t.Fatalf("expected the duration rejected, got %v", err)
+	}
+
+	// the OCO stop leg can not wait StopLossDuration.
+	if err := ioutil.WriteFile(file, []byte(testConfig+"  OCO: true\n  StopLossDuration: 1m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOption(file); err == nil || !strings.Contains(err.Error(), "StopLossDuration 1m0s is not supported") {
+		t.Fatalf("expected OCO with StopLossDuration rejected, got %v", err)
+	}
+
+	// bare numbers were seconds before durations took units.
+	if err := ioutil.WriteFile(file, []byte(strings.Replace(testConfig, "Interval: 1s", "Interval: 1", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOption(file); err == nil || !strings.Contains(err.Error(), `BuyOption.Interval: 1 has no unit, use a duration like "1s"`) {
+		t.Fatalf("expected the bare number rejected, got %v", err)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(file, []byte(testConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	option, err := LoadOption(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := NewTrade(WithBuyOption(option.BuyOption), WithSellOption(option.SellOption))
+	tr.configFile = file
+	tr.boughtInfo["DOGEUSDT"] = &BoughtInfo{Symbol: "DOGEUSDT", OrderId: 1, TakeProfit: 2}
+
+	reload := func(config string) ([]string, error) {
+		if err := ioutil.WriteFile(file, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return tr.ReloadConfig()
+	}
+
+	changes, err := reload(strings.NewReplacer("MaxBuy: 2", "MaxBuy: 3", "Interval: 1s\n  TakeProfit", "Interval: 5s\n  TakeProfit").Replace(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0] != "BuyOption.MaxBuy: 2 -> 3" || changes[1] != "SellOption.Interval: 1s -> 5s" {
+		t.Fatalf("unexpected changes %q", changes)
+	}
+	if o := tr.Option(); o.BuyOption.MaxBuy != 3 || o.SellOption.Interval != 5*time.Second {
+		t.Fatalf("expected the option swapped, got %+v", o)
+	}
+	select {
+	case <-tr.reloadChan:
+	default:
+		t.Fatal("expected the watch loop notified")
+	}
+	if len(tr.getBoughtInfo()) != 1 {
+		t.Fatal("expected the positions kept")
+	}
+
+	invalid := []string{
+		strings.Replace(testConfig, "MoneyPerOrder: 11", "MoneyPerOrder: 0", 1),
+		strings.Replace(testConfig, "MainCoin: USDT", "MainCoin: BUSD", 1),
+		"BuyOption: [",
+	}
+	for _, config := range invalid {
+		if _, err := reload(config); err == nil {
+			t.Fatalf("expected %q rejected", config)
+		}
+	}
+	if o := tr.Option(); o.BuyOption.MaxBuy != 3 || o.BuyOption.MoneyPerOrder != 11 {
+		t.Fatalf("expected the option kept, got %+v", o.BuyOption)
+	}
+}
@@ -0,0 +1,81 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// BuyNow buys the symbol at the current price, MaxBuy and the positions we hold are respected.
+func (t *Trade) BuyNow(ctx context.Context, symbol string) (*BoughtInfo, error) {
+	prices := t.GetSymbolPrice(ctx, symbol)
+	pr, ok := prices[symbol]
+	if !ok {
+		return nil, fmt.Errorf("price not found: %s", symbol)
+	}
+	info, err := t.buyPosition(ctx, symbol, pr.Price, nil)
+	if err != nil {
+		return nil, err
+	}
+	buysTotal.WithLabelValues(t.metricsLabel(), buyReasonManual).Inc()
+	t.save()
+	return info, nil
+}
+
+// SellNow enqueues a manual sell of the position.
+func (t *Trade) SellNow(ctx context.Context, symbol string) error {
+	t.boughtMutex.Lock()
+	info, ok := t.boughtInfo[symbol]
+	var i BoughtInfo
+	if ok {
+		i = *info
+	}
+	t.boughtMutex.Unlock()
+	if !ok {
+		return fmt.Errorf("position not found: %s", symbol)
+	}
+	return t.enqueueSell(ctx, &i, SellReasonManual)
+}
+
+// LiquidateAll enqueues manual sells of all positions, returns how many are enqueued.
+func (t *Trade) LiquidateAll(ctx context.Context) (int, error) {
+	var count int
+	for _, info := range t.getBoughtInfo() {
+		if err := t.enqueueSell(ctx, info, SellReasonManual); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (t *Trade) enqueueSell(ctx context.Context, info *BoughtInfo, reason SellReason) error {
+	var priceChange float64
+	if pr, ok := t.GetSymbolPrice(ctx, info.Symbol)[info.Symbol]; ok {
+		price := info.GetPrice()
+		priceChange = (pr.Price - price) / price * 100
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case t.sellChan <- &SellBill{Info: info, Reason: reason, PriceChange: priceChange}:
+	}
+	return nil
+}
+
+// Pause stops buying, positions are still watched for TP/SL.
+func (t *Trade) Pause() {
+	atomic.StoreInt32(&t.paused, 1)
+	t.logger.Info("buying paused")
+}
+
+// Resume starts buying again.
+func (t *Trade) Resume() {
+	atomic.StoreInt32(&t.paused, 0)
+	t.logger.Info("buying resumed")
+}
+
+// Paused reports whether buying is paused.
+func (t *Trade) Paused() bool {
+	return atomic.LoadInt32(&t.paused) == 1
+}
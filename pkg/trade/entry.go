@@ -0,0 +1,166 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"strconv"
+	"time"
+)
+
+const (
+	EntryMarket     = "market"
+	EntryLimit      = "limit"
+	EntryLimitMaker = "limit_maker"
+
+	DefaultEntryTimeout = 10 * time.Second
+
+	// entryCancelTimeout bounds cancelling the leftover of an entry order once ctx is done.
+	entryCancelTimeout = 10 * time.Second
+)
+
+// bestBid returns the best bid of the symbol, from the price stream if it is alive, or else by REST.
+func (t *Trade) bestBid(ctx context.Context, symbol string) (float64, error) {
+	if t.stream != nil && t.stream.fresh() {
+		if pr, ok := t.book.snapshot(symbol)[symbol]; ok && pr.Bid > 0 {
+			return pr.Bid, nil
+		}
+	}
+	tickers, err := t.exchange.BookTickers(ctx, symbol)
+	if err != nil {
+		return 0, err
+	}
+	for _, tk := range tickers {
+		if tk.Symbol == symbol {
+			return strconv.ParseFloat(tk.BidPrice, 64)
+		}
+	}
+	return 0, fmt.Errorf("book ticker not found: %s", symbol)
+}
+
+// limitBuy buys money of symbol with limit orders at the best bid plus EntryOffset.
+// The leftover of an order is cancelled after EntryTimeout, and placed again at the new bid
+// up to EntryReprices times. The returned order sums up the fills of all orders.
+func (t *Trade) limitBuy(ctx context.Context, rules *SymbolRules, money float64) (*binance.Order, *binance.CreateOrderResponse, error) {
+	option := t.Option().BuyOption
+	symbol := rules.Symbol
+	orderType := binance.OrderTypeLimit
+	switch option.EntryMode {
+	case EntryLimit:
+	case EntryLimitMaker:
+		orderType = binance.OrderTypeLimitMaker
+	default:
+		return nil, nil, fmt.Errorf("unknown entry mode: %s", option.EntryMode)
+	}
+	timeout := option.EntryTimeout
+	if timeout == 0 {
+		timeout = DefaultEntryTimeout
+	}
+
+	var (
+		first    *binance.Order
+		last     *binance.Order
+		executed float64
+		quote    float64
+		fills    []*binance.Fill
+		lastErr  error
+	)
+	for i := 0; i <= option.EntryReprices && ctx.Err() == nil; i++ {
+		bid, err := t.bestBid(ctx, symbol)
+		if err != nil {
+			lastErr = err
+			break
+		}
+		price := rules.RoundPrice(bid * (1 + option.EntryOffset/100))
+		number := rules.RoundQuantity((money-quote)/price, false)
+		if err := rules.Validate(number, price, false); err != nil {
+			// the leftover of a partially filled entry may be too small to place.
+			lastErr = err
+			break
+		}
+		if err := rules.ValidatePercentPrice(price, bid); err != nil {
+			lastErr = err
+			break
+		}
+		resp, err := t.exchange.CreateOrder(ctx, OrderRequest{
+			Symbol:   symbol,
+			Side:     binance.SideTypeBuy,
+			Type:     orderType,
+			Quantity: number,
+			Price:    price,
+		})
+		if err != nil {
+			// eg: a LIMIT_MAKER crossing the book, the next try uses the new bid.
+			t.filterFailed(err)
+			t.logger.WithError(err).Warnf("failed to place entry order of %s at %f", symbol, price)
+			lastErr = err
+			continue
+		}
+		order, err := t.waitOrder(ctx, symbol, resp.OrderID, timeout)
+		if err != nil {
+			// the order state is unknown, never place another one.
+			lastErr = err
+			break
+		}
+		filled, _ := strconv.ParseFloat(order.ExecutedQuantity, 64)
+		if filled == 0 {
+			continue
+		}
+		cumQuote, _ := strconv.ParseFloat(order.CummulativeQuoteQuantity, 64)
+		executed += filled
+		quote += cumQuote
+		if first == nil {
+			first = order
+		}
+		last = order
+		f, err := t.exchange.OrderFills(ctx, symbol, order.OrderID)
+		if err != nil {
+			t.logger.WithError(err).Errorf("failed to query fills of %s, commission is ignored", symbol)
+		}
+		fills = append(fills, f...)
+		if order.Status == binance.OrderStatusTypeFilled {
+			break
+		}
+	}
+	if first == nil {
+		if lastErr == nil {
+			lastErr = fmt.Errorf("entry order of %s not filled in %s", symbol, timeout)
+		}
+		return nil, nil, lastErr
+	}
+	t.logger.Infof("entered %s with limit orders: %f at %f", symbol, executed, quote/executed)
+
+	order := *first
+	order.ExecutedQuantity = formatFloat(executed)
+	order.CummulativeQuoteQuantity = formatFloat(quote)
+	order.Status = last.Status
+	order.UpdateTime = last.UpdateTime
+	return &order, orderResponse(&order, fills), nil
+}
+
+// waitOrder polls the order until it is done or the timeout, then the leftover is cancelled.
+// The leftover is cancelled even if ctx is done, or it would rest on the exchange untracked.
+func (t *Trade) waitOrder(ctx context.Context, symbol string, id int64, timeout time.Duration) (*binance.Order, error) {
+	interval := timeout / 5
+	if interval > time.Second {
+		interval = time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		order, err := t.exchange.GetOrder(ctx, symbol, id)
+		if err == nil && order.Status != binance.OrderStatusTypeNew && order.Status != binance.OrderStatusTypePartiallyFilled {
+			return order, nil
+		}
+		select {
+		case <-ctx.Done():
+			deadline = time.Now()
+		case <-time.After(interval):
+		}
+	}
+	cctx, cancel := context.WithTimeout(WithPriority(context.Background(), PrioritySell), entryCancelTimeout)
+	defer cancel()
+	if _, err := t.exchange.CancelOrder(cctx, symbol, id); err != nil {
+		t.logger.WithError(err).Warnf("failed to cancel entry order %d of %s", id, symbol)
+	}
+	return t.GetOrder(cctx, symbol, id, 0)
+}
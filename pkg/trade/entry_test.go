@@ -0,0 +1,155 @@
+package trade
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2"
+	"math"
+	"testing"
+	"time"
+)
+
+// entryExchange calls onPoll on the first poll of each order, so the tests move prices
+// while an entry order rests instead of racing it. Like the rate limited client,
+// it fails requests made with a done ctx.
+type entryExchange struct {
+	Exchange
+	onPoll func(id int64)
+	polled map[int64]bool
+}
+
+func (e *entryExchange) GetOrder(ctx context.Context, symbol string, id int64) (*binance.Order, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !e.polled[id] {
+		e.polled[id] = true
+		e.onPoll(id)
+	}
+	return e.Exchange.GetOrder(ctx, symbol, id)
+}
+
+func (e *entryExchange) CancelOrder(ctx context.Context, symbol string, id int64) (*binance.CancelOrderResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return e.Exchange.CancelOrder(ctx, symbol, id)
+}
+
+func newEntryTrade(sim *SimExchange, mode string, reprices int, onPoll func(id int64)) *Trade {
+	tr := NewTrade(
+		WithBuyOption(BuyOption{
+			MaxBuy:        1,
+			MoneyPerOrder: 20,
+			MainCoin:      "USDT",
+			WhiteList:     []string{"DOGE"},
+			EntryMode:     mode,
+			EntryOffset:   -1,
+			EntryTimeout:  100 * time.Millisecond,
+			EntryReprices: reprices,
+		}),
+		WithSellOption(testSellOption),
+	)
+	tr.SetExchange(&entryExchange{Exchange: sim, onPoll: onPoll, polled: make(map[int64]bool)})
+	return tr
+}
+
+func TestLimitEntryPartialFill(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1.00)
+	sim.PartialFill = 0.5
+	// the bid of 0.99 is crossed once, half of the order fills before the timeout.
+	tr := newEntryTrade(sim, EntryLimit, 0, func(id int64) {
+		sim.SetPrice("DOGEUSDT", 0.98)
+	})
+
+	info, err := tr.buyPosition(context.Background(), "DOGEUSDT", 1.00, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// half of 20.20 DOGE.
+	if info.ExecutedQuantity != 10.1 || info.Volume != 10.1 {
+		t.Fatalf("expected 10.10 DOGE bought, got %f", info.ExecutedQuantity)
+	}
+	if want := 10.1 * 0.99; math.Abs(info.CummulativeQuoteQuantity-want) > 1e-9 {
+		t.Fatalf("expected cost %f, got %f", want, info.CummulativeQuoteQuantity)
+	}
+	o, _ := sim.GetOrder(context.Background(), "DOGEUSDT", info.OrderId)
+	if o.Status != binance.OrderStatusTypeCanceled {
+		t.Fatalf("expected the leftover cancelled, got %s", o.Status)
+	}
+	// the lock of the leftover is released.
+	if want := 100 - 10.1*0.99; math.Abs(sim.Balance("USDT")-want) > 1e-9 {
+		t.Fatalf("expected balance %f, got %f", want, sim.Balance("USDT"))
+	}
+}
+
+func TestLimitEntryReprice(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1.00)
+	// the price runs away from 0.99, the order is placed again at 1.089.
+	prices := []float64{1.10, 1.08}
+	tr := newEntryTrade(sim, EntryLimitMaker, 1, func(id int64) {
+		sim.SetPrice("DOGEUSDT", prices[0])
+		prices = prices[1:]
+	})
+
+	info, err := tr.buyPosition(context.Background(), "DOGEUSDT", 1.00, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ExecutedQuantity != 18.36 || info.GetPrice() != 1.089 {
+		t.Fatalf("expected 18.36 DOGE bought at 1.089, got %f at %f", info.ExecutedQuantity, info.GetPrice())
+	}
+}
+
+func TestLimitEntryNotFilled(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1.00)
+	tr := newEntryTrade(sim, EntryLimit, 0, func(id int64) {})
+
+	if _, err := tr.buyPosition(context.Background(), "DOGEUSDT", 1.00, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(tr.getBoughtInfo()) != 0 {
+		t.Fatal("expected no position")
+	}
+	if sim.Balance("USDT") != 100 {
+		t.Fatalf("expected the balance released, got %f", sim.Balance("USDT"))
+	}
+}
+
+func TestLimitEntryCancelledOnStop(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1.00)
+	ctx, cancel := context.WithCancel(context.Background())
+	var entry int64
+	// the bot is stopped while the entry order rests.
+	tr := newEntryTrade(sim, EntryLimit, 2, func(id int64) {
+		entry = id
+		cancel()
+	})
+
+	if _, err := tr.buyPosition(ctx, "DOGEUSDT", 1.00, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	o, _ := sim.GetOrder(context.Background(), "DOGEUSDT", entry)
+	if o.Status != binance.OrderStatusTypeCanceled {
+		t.Fatalf("expected the entry order cancelled, got %s", o.Status)
+	}
+	if sim.Balance("USDT") != 100 {
+		t.Fatalf("expected the balance released, got %f", sim.Balance("USDT"))
+	}
+}
+
+func TestSignalsDroppedWhileBuying(t *testing.T) {
+	tr := newEntryTrade(newTestSim(), EntryLimit, 0, func(id int64) {})
+	signals := []Signal{{Symbol: "DOGEUSDT"}}
+
+	if !tr.offerSignals(signals) {
+		t.Fatal("expected the signals handed to runBuy")
+	}
+	// runBuy is still entering, the watch loop must not block on it.
+	if tr.offerSignals(signals) {
+		t.Fatal("expected the signals dropped")
+	}
+}
@@ -0,0 +1,166 @@
+package trade
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2"
+	"strconv"
+)
+
+// OrderRequest defines the order we want to place on an exchange.
+type OrderRequest struct {
+	Symbol   string
+	Side     binance.SideType
+	Type     binance.OrderType
+	Quantity float64
+
+	// Price the limit price, LIMIT orders are good till cancelled.
+	Price float64
+}
+
+// OCORequest defines a one-cancels-the-other order: a limit order at Price
+// and a stop-limit order at StopLimitPrice triggered by StopPrice.
+type OCORequest struct {
+	Symbol         string
+	Side           binance.SideType
+	Quantity       float64
+	Price          float64
+	StopPrice      float64
+	StopLimitPrice float64
+}
+
+// Exchange defines the backend Trade talks to, the binance REST api is one implementation.
+type Exchange interface {
+	// ListPrices returns the latest price of the symbol, or all symbols if symbol is empty.
+	ListPrices(ctx context.Context, symbol string) ([]*binance.SymbolPrice, error)
+
+	// BookTickers returns the best bid and ask of the symbol, or all symbols if symbol is empty.
+	BookTickers(ctx context.Context, symbol string) ([]*binance.BookTicker, error)
+
+	// ExchangeInfo returns trading rules and symbol information.
+	ExchangeInfo(ctx context.Context) (*binance.ExchangeInfo, error)
+
+	// CreateOrder places an order.
+	CreateOrder(ctx context.Context, req OrderRequest) (*binance.CreateOrderResponse, error)
+
+	// GetOrder returns the order with given id.
+	GetOrder(ctx context.Context, symbol string, id int64) (*binance.Order, error)
+
+	// Balances returns the balances of the account.
+	Balances(ctx context.Context) ([]binance.Balance, error)
+
+	// CreateOCO places an OCO order list.
+	CreateOCO(ctx context.Context, req OCORequest) (*binance.CreateOCOResponse, error)
+
+	// CancelOrder cancels an open order, cancelling a leg of an OCO cancels the list.
+	CancelOrder(ctx context.Context, symbol string, id int64) (*binance.CancelOrderResponse, error)
+
+	// OrderFills returns the fills of an order with commissions.
+	OrderFills(ctx context.Context, symbol string, id int64) ([]*binance.Fill, error)
+}
+
+type binanceExchange struct {
+	client *binance.Client
+}
+
+// NewBinanceExchange returns an Exchange backed by binance REST api.
+func NewBinanceExchange(client *binance.Client) Exchange {
+	return &binanceExchange{client: client}
+}
+
+func (b *binanceExchange) ListPrices(ctx context.Context, symbol string) ([]*binance.SymbolPrice, error) {
+	svc := b.client.NewListPricesService()
+	if symbol != "" {
+		svc.Symbol(symbol)
+	}
+	return svc.Do(ctx)
+}
+
+func (b *binanceExchange) ExchangeInfo(ctx context.Context) (*binance.ExchangeInfo, error) {
+	return b.client.NewExchangeInfoService().Do(ctx)
+}
+
+func (b *binanceExchange) BookTickers(ctx context.Context, symbol string) ([]*binance.BookTicker, error) {
+	svc := b.client.NewListBookTickersService()
+	if symbol != "" {
+		svc.Symbol(symbol)
+	}
+	return svc.Do(ctx)
+}
+
+func (b *binanceExchange) CreateOrder(ctx context.Context, req OrderRequest) (*binance.CreateOrderResponse, error) {
+	svc := b.client.NewCreateOrderService().
+		Symbol(req.Symbol).
+		Side(req.Side).
+		Type(req.Type).
+		Quantity(strconv.FormatFloat(req.Quantity, 'g', -1, 64)).
+		NewOrderRespType(binance.NewOrderRespTypeFULL)
+	if req.Type != binance.OrderTypeMarket {
+		svc.Price(strconv.FormatFloat(req.Price, 'g', -1, 64))
+	}
+	if req.Type == binance.OrderTypeLimit {
+		svc.TimeInForce(binance.TimeInForceTypeGTC)
+	}
+	return svc.Do(ctx, binance.WithRecvWindow(50000))
+}
+
+func (b *binanceExchange) GetOrder(ctx context.Context, symbol string, id int64) (*binance.Order, error) {
+	return b.client.NewGetOrderService().
+		Symbol(symbol).
+		OrderID(id).
+		Do(ctx, binance.WithRecvWindow(50000))
+}
+
+func (b *binanceExchange) Balances(ctx context.Context) ([]binance.Balance, error) {
+	account, err := b.client.NewGetAccountService().Do(ctx, binance.WithRecvWindow(50000))
+	if err != nil {
+		return nil, err
+	}
+	return account.Balances, nil
+}
+
+func (b *binanceExchange) CreateOCO(ctx context.Context, req OCORequest) (*binance.CreateOCOResponse, error) {
+	return b.client.NewCreateOCOService().
+		Symbol(req.Symbol).
+		Side(req.Side).
+		Quantity(strconv.FormatFloat(req.Quantity, 'g', -1, 64)).
+		Price(strconv.FormatFloat(req.Price, 'g', -1, 64)).
+		StopPrice(strconv.FormatFloat(req.StopPrice, 'g', -1, 64)).
+		StopLimitPrice(strconv.FormatFloat(req.StopLimitPrice, 'g', -1, 64)).
+		StopLimitTimeInForce(binance.TimeInForceTypeGTC).
+		Do(ctx, binance.WithRecvWindow(50000))
+}
+
+func (b *binanceExchange) CancelOrder(ctx context.Context, symbol string, id int64) (*binance.CancelOrderResponse, error) {
+	return b.client.NewCancelOrderService().
+		Symbol(symbol).
+		OrderID(id).
+		Do(ctx, binance.WithRecvWindow(50000))
+}
+
+func (b *binanceExchange) OrderFills(ctx context.Context, symbol string, id int64) ([]*binance.Fill, error) {
+	order, err := b.GetOrder(ctx, symbol, id)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := b.client.NewListTradesService().
+		Symbol(symbol).
+		StartTime(order.Time).
+		Limit(1000).
+		Do(ctx, binance.WithRecvWindow(50000))
+	if err != nil {
+		return nil, err
+	}
+	var fills []*binance.Fill
+	for _, trade := range trades {
+		if trade.OrderID != id {
+			continue
+		}
+		fills = append(fills, &binance.Fill{
+			Price:           trade.Price,
+			Quantity:        trade.Quantity,
+			Commission:      trade.Commission,
+			CommissionAsset: trade.CommissionAsset,
+		})
+	}
+	return fills, nil
+}
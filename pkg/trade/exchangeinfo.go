@@ -0,0 +1,166 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultExchangeInfoInterval = time.Hour
+
+// exchangeInfoCache keeps the exchange info, it is heavy to download for each order.
+type exchangeInfoCache struct {
+	// loadMutex serializes downloads, so concurrent misses download once.
+	loadMutex sync.Mutex
+
+	mu      sync.RWMutex
+	info    *binance.ExchangeInfo
+	symbols map[string]binance.Symbol
+	rules   map[string]*SymbolRules
+	updated time.Time
+
+	// stale the cache is reloaded on the next lookup.
+	stale bool
+}
+
+func newExchangeInfoCache() *exchangeInfoCache {
+	return &exchangeInfoCache{}
+}
+
+// reset drops the cache, eg: the exchange is replaced.
+func (c *exchangeInfoCache) reset() {
+	c.mu.Lock()
+	c.info = nil
+	c.symbols = nil
+	c.rules = nil
+	c.mu.Unlock()
+}
+
+func (c *exchangeInfoCache) invalidate() {
+	c.mu.Lock()
+	c.stale = true
+	c.mu.Unlock()
+}
+
+func (c *exchangeInfoCache) valid() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.info != nil && !c.stale
+}
+
+// get returns a copy of the info with only the symbols, or all if none given. nil if the cache is empty.
+func (c *exchangeInfoCache) get(symbols []string) *binance.ExchangeInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.info == nil {
+		return nil
+	}
+	info := *c.info
+	if len(symbols) == 0 {
+		return &info
+	}
+	info.Symbols = nil
+	for _, symbol := range symbols {
+		if s, ok := c.symbols[symbol]; ok {
+			info.Symbols = append(info.Symbols, s)
+		}
+	}
+	return &info
+}
+
+// RefreshExchangeInfo downloads the exchange info and replaces the cache.
+func (t *Trade) RefreshExchangeInfo(ctx context.Context) error {
+	info, err := t.exchange.ExchangeInfo(ctx)
+	if err != nil {
+		return err
+	}
+	symbols := make(map[string]binance.Symbol, len(info.Symbols))
+	rules := make(map[string]*SymbolRules, len(info.Symbols))
+	for _, s := range info.Symbols {
+		symbols[s.Symbol] = s
+		if r, err := NewSymbolRules(s); err == nil {
+			rules[s.Symbol] = r
+		}
+	}
+
+	c := t.exchangeInfo
+	c.mu.Lock()
+	c.info = info
+	c.symbols = symbols
+	c.rules = rules
+	c.updated = t.now()
+	c.stale = false
+	c.mu.Unlock()
+
+	t.logger.Debugf("loaded exchange info of %d symbols", len(symbols))
+	t.rebuildUniverse()
+	return nil
+}
+
+// loadExchangeInfo downloads the exchange info if the cache is empty or stale.
+func (t *Trade) loadExchangeInfo(ctx context.Context) error {
+	c := t.exchangeInfo
+	if c.valid() {
+		return nil
+	}
+	c.loadMutex.Lock()
+	defer c.loadMutex.Unlock()
+
+	if c.valid() {
+		return nil
+	}
+	return t.RefreshExchangeInfo(ctx)
+}
+
+// runExchangeInfo refreshes the exchange info every ExchangeInfoInterval.
+func (t *Trade) runExchangeInfo(ctx context.Context) {
+	interval := t.Option().SystemOption.ExchangeInfoInterval
+	if interval == 0 {
+		interval = DefaultExchangeInfoInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.RefreshExchangeInfo(ctx); err != nil {
+				t.logger.WithError(err).Error("failed to refresh exchange info")
+			}
+		}
+	}
+}
+
+// Symbol returns the exchange info of the symbol by name.
+func (t *Trade) Symbol(ctx context.Context, symbol string) (*binance.Symbol, error) {
+	if err := t.loadExchangeInfo(ctx); err != nil {
+		return nil, err
+	}
+	c := t.exchangeInfo
+	c.mu.RLock()
+	s, ok := c.symbols[symbol]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("symbol not found: %s", symbol)
+	}
+	return &s, nil
+}
+
+// filterFailed drops the cached exchange info if binance rejected an order by its filters,
+// they changed since the cache was loaded.
+func (t *Trade) filterFailed(err error) {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == -1013 && strings.HasPrefix(apiErr.Message, "Filter failure") {
+		t.logger.Warnf("%s, exchange info is reloaded", apiErr.Message)
+		t.exchangeInfo.invalidate()
+	}
+}
@@ -0,0 +1,98 @@
+package trade
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+	"testing"
+)
+
+// countingExchange counts the exchange info downloads, the orders and the prices queried
+type countingExchange struct {
+	Exchange
+	infos      int
+	getOrders  int
+	listPrices int
+}
+
+func (c *countingExchange) ExchangeInfo(ctx context.Context) (*binance.ExchangeInfo, error) {
+	c.infos++
+	return c.Exchange.ExchangeInfo(ctx)
+}
+
+func (c *countingExchange) GetOrder(ctx context.Context, symbol string, id int64) (*binance.Order, error) {
+	c.getOrders++
+	return c.Exchange.GetOrder(ctx, symbol, id)
+}
+
+func (c *countingExchange) ListPrices(ctx context.Context, symbol string) ([]*binance.SymbolPrice, error) {
+	c.listPrices++
+	return c.Exchange.ListPrices(ctx, symbol)
+}
+
+func TestExchangeInfoCache(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1)
+	sim.SetPrice("ETHUSDT", 1)
+	r := newSimRunner(t, sim, testSellOption)
+	ex := &countingExchange{Exchange: sim}
+	r.trade.SetExchange(ex)
+	ctx := context.Background()
+
+	for _, symbol := range []string{"DOGEUSDT", "ETHUSDT"} {
+		if _, err := r.trade.buyPosition(ctx, symbol, 1, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ex.infos != 1 {
+		t.Fatalf("expected exchange info downloaded once, got %d", ex.infos)
+	}
+
+	info, err := r.trade.GetExchangeInfo(ctx, "ETHUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Symbols) != 1 || info.Symbols[0].Symbol != "ETHUSDT" {
+		t.Fatalf("expected only ETHUSDT, got %d symbols", len(info.Symbols))
+	}
+	if _, err := r.trade.Symbol(ctx, "BTCUSDT"); err == nil {
+		t.Fatal("expected BTCUSDT not found")
+	}
+
+	// binance rejecting an order by its filters reloads the exchange info.
+	r.trade.filterFailed(&common.APIError{Code: -1013, Message: "Filter failure: LOT_SIZE"})
+	if _, err := r.trade.SymbolRules(ctx, "DOGEUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	if ex.infos != 2 {
+		t.Fatalf("expected exchange info reloaded, got %d downloads", ex.infos)
+	}
+}
+
+func TestExchangeInfoReset(t *testing.T) {
+	sim := newTestSim()
+	r := newSimRunner(t, sim, testSellOption)
+	ctx := context.Background()
+
+	// the exchange replaced while the info is read.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				r.trade.exchangeInfo.reset()
+			}
+		}
+	}()
+	for i := 0; i < 10000; i++ {
+		if _, err := r.trade.GetExchangeInfo(ctx, "DOGEUSDT"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	<-done
+}
@@ -1,5 +1,71 @@
 package trade
 
+import (
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// EventType defines what happened in the trade
+type EventType string
+
+const (
+	EventBuy      EventType = "buy"
+	EventSell     EventType = "sell"
+	EventTrailing EventType = "trailing"
+	EventError    EventType = "error"
+)
+
+// Event defines what hooks receive, Position is set for buy and trailing, Sell for sell.
+type Event struct {
+	Type     EventType   `json:"type"`
+	Time     time.Time   `json:"time"`
+	Symbol   string      `json:"symbol,omitempty"`
+	Position *BoughtInfo `json:"position,omitempty"`
+	Sell     *SellBill   `json:"sell,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// Hook receives the events of the trade, every event is delivered in its own goroutine.
+type Hook interface {
+	OnEvent(e Event)
+}
+
+// HookFunc adapts a function to Hook
+type HookFunc func(e Event)
+
+func (f HookFunc) OnEvent(e Event) {
+	f(e)
+}
+
+// AddHook adds a hook receiving the events of the trade
+func (t *Trade) AddHook(h Hook) {
+	t.hookMutex.Lock()
+	t.hooks = append(t.hooks, h)
+	t.hookMutex.Unlock()
+}
+
+func (t *Trade) emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = t.now()
+	}
+	t.hookMutex.Lock()
+	hooks := t.hooks
+	t.hookMutex.Unlock()
+	for _, h := range hooks {
+		go h.OnEvent(e)
+	}
+}
+
+func (t *Trade) emitError(symbol string, err error) {
+	t.emit(Event{Type: EventError, Symbol: symbol, Error: err.Error()})
+}
+
 func (t *Trade) OnTrailingTakeProfit(info *BoughtInfo) {
+	c := *info
+	t.emit(Event{Type: EventTrailing, Symbol: info.Symbol, Position: &c})
+}
 
+// Logger returns the logger of the trade
+func (t *Trade) Logger() logrus.FieldLogger {
+	return t.logger
 }
@@ -0,0 +1,284 @@
+package trade
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/adshao/go-binance/v2"
+	bolt "go.etcd.io/bbolt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	journalEntriesBucket   = []byte("entries")
+	journalSnapshotsBucket = []byte("snapshots")
+)
+
+// JournalEntryType defines what happened
+type JournalEntryType string
+
+const (
+	JournalBuy  JournalEntryType = "buy"
+	JournalSell JournalEntryType = "sell"
+)
+
+// JournalEntry defines an order the bot placed
+type JournalEntry struct {
+	ID       uint64           `json:"id"`
+	Type     JournalEntryType `json:"type"`
+	Symbol   string           `json:"symbol"`
+	OrderID  int64            `json:"orderId"`
+	Time     time.Time        `json:"time"`
+	Price    float64          `json:"price"`
+	Quantity float64          `json:"quantity"`
+	Quote    float64          `json:"quote"`
+	Fee      float64          `json:"fee"`
+	FeeAsset string           `json:"feeAsset"`
+
+	// Commission the fee converted to the quote asset at fill time
+	Commission float64 `json:"commission"`
+
+	// BuyOrderID the buy order a sell closes
+	BuyOrderID  int64      `json:"buyOrderId,omitempty"`
+	Reason      SellReason `json:"reason,omitempty"`
+	PriceChange float64    `json:"priceChange,omitempty"`
+	PnL         *TradePnL  `json:"pnl,omitempty"`
+
+	// SnapshotID the config the order was placed with, see Journal.Snapshot
+	SnapshotID string `json:"snapshotId"`
+
+	// Imported the entry was migrated from BoughtFile, prices and fees may be incomplete.
+	Imported bool `json:"imported,omitempty"`
+}
+
+// JournalQuery filters journal entries, zero values match all.
+type JournalQuery struct {
+	Symbol string
+	Type   JournalEntryType
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (q JournalQuery) match(e *JournalEntry) bool {
+	if q.Symbol != "" && q.Symbol != e.Symbol {
+		return false
+	}
+	if q.Type != "" && q.Type != e.Type {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !e.Time.Before(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Journal records every order the bot placed in an embedded bbolt database.
+type Journal struct {
+	db *bolt.DB
+}
+
+// OpenJournal opens or creates the journal file
+func OpenJournal(file string) (*Journal, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(journalEntriesBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(journalSnapshotsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Journal{db: db}, nil
+}
+
+func (j *Journal) Close() error {
+	return j.db.Close()
+}
+
+// Record saves the entry with the config snapshot, the ID is assigned.
+func (j *Journal) Record(e *JournalEntry, option Option) error {
+	option.SystemOption.AccessKey = ""
+	option.SystemOption.SecretKey = ""
+	option.SystemOption.APIToken = ""
+	option.SystemOption.SignalToken = ""
+	option.SystemOption.TelegramToken = ""
+	option.SystemOption.Webhooks = nil
+	snapshot, err := json.Marshal(option)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(snapshot)
+	e.SnapshotID = hex.EncodeToString(sum[:8])
+
+	return j.db.Update(func(tx *bolt.Tx) error {
+		snapshots := tx.Bucket(journalSnapshotsBucket)
+		if snapshots.Get([]byte(e.SnapshotID)) == nil {
+			if err := snapshots.Put([]byte(e.SnapshotID), snapshot); err != nil {
+				return err
+			}
+		}
+		return j.put(tx, e)
+	})
+}
+
+func (j *Journal) put(tx *bolt.Tx, e *JournalEntry) error {
+	entries := tx.Bucket(journalEntriesBucket)
+	id, err := entries.NextSequence()
+	if err != nil {
+		return err
+	}
+	e.ID = id
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return entries.Put(journalKey(id), data)
+}
+
+func journalKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// Entries returns the entries matching the query, newest first
+func (j *Journal) Entries(q JournalQuery) ([]*JournalEntry, error) {
+	var res = make([]*JournalEntry, 0)
+	err := j.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(journalEntriesBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var e JournalEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if !q.match(&e) {
+				continue
+			}
+			res = append(res, &e)
+			if q.Limit > 0 && len(res) >= q.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Snapshot returns the config an entry was recorded with
+func (j *Journal) Snapshot(id string) (*Option, error) {
+	var option *Option
+	err := j.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(journalSnapshotsBucket).Get([]byte(id))
+		if data == nil {
+			return errors.New("snapshot not found: " + id)
+		}
+		option = new(Option)
+		return json.Unmarshal(data, option)
+	})
+	return option, err
+}
+
+// ImportBoughtFile migrates the positions of a BoughtFile as buy entries,
+// positions already in the journal are skipped. It returns how many are imported.
+func (j *Journal) ImportBoughtFile(file string) (int, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var bought map[string]*BoughtInfo
+	if err := json.Unmarshal(data, &bought); err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = j.db.Update(func(tx *bolt.Tx) error {
+		known := make(map[int64]bool)
+		err := tx.Bucket(journalEntriesBucket).ForEach(func(k, v []byte) error {
+			var e JournalEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			if e.Type == JournalBuy {
+				known[e.OrderID] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, info := range bought {
+			if known[info.OrderId] {
+				continue
+			}
+			e := &JournalEntry{
+				Type:     JournalBuy,
+				Symbol:   info.Symbol,
+				OrderID:  info.OrderId,
+				Time:     info.Time,
+				Quantity: info.ExecutedQuantity,
+				Quote:    info.CummulativeQuoteQuantity,
+				Imported: true,
+			}
+			if info.ExecutedQuantity != 0 {
+				e.Price = info.GetPrice()
+			}
+			if err := j.put(tx, e); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// journalFills sums the fills of an order response.
+func journalFills(e *JournalEntry, resp *binance.CreateOrderResponse) {
+	e.OrderID = resp.OrderID
+	e.Quantity, _ = strconv.ParseFloat(resp.ExecutedQuantity, 64)
+	e.Quote, _ = strconv.ParseFloat(resp.CummulativeQuoteQuantity, 64)
+	if e.Quantity != 0 {
+		e.Price = e.Quote / e.Quantity
+	}
+	for _, f := range resp.Fills {
+		fee, _ := strconv.ParseFloat(f.Commission, 64)
+		e.Fee += fee
+		e.FeeAsset = f.CommissionAsset
+	}
+}
+
+// Journal returns the journal of the trade, nil if JournalFile is not set.
+func (t *Trade) Journal() *Journal {
+	return t.journal
+}
+
+// record saves the order to the journal, failures are only logged.
+func (t *Trade) record(e *JournalEntry, resp *binance.CreateOrderResponse) {
+	if t.journal == nil {
+		return
+	}
+	if resp != nil {
+		journalFills(e, resp)
+	}
+	if err := t.journal.Record(e, t.Option()); err != nil {
+		t.logger.WithError(err).Errorf("failed to record %s %s to journal", e.Type, e.Symbol)
+	}
+}
@@ -0,0 +1,84 @@
+package trade
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := OpenJournal(filepath.Join(dir, "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	sim := newTestSim()
+	sim.Fee = 0.001
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.05)
+	r := newSimRunner(t, sim, testSellOption)
+	r.trade.journal = journal
+	r.run(context.Background())
+
+	entries, err := journal.Entries(JournalQuery{Symbol: "DOGEUSDT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	sell, buy := entries[0], entries[1]
+	if buy.Type != JournalBuy || sell.Type != JournalSell {
+		t.Fatalf("unexpected entries %+v %+v", buy, sell)
+	}
+	if sell.BuyOrderID != buy.OrderID || sell.Reason != SellReasonForTakeProfit {
+		t.Fatalf("unexpected sell %+v", sell)
+	}
+	if buy.Price != 1.02 || buy.Fee == 0 || buy.FeeAsset != "USDT" {
+		t.Fatalf("unexpected buy %+v", buy)
+	}
+
+	option, err := journal.Snapshot(buy.SnapshotID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if option.SellOption.TakeProfit != testSellOption.TakeProfit {
+		t.Fatalf("unexpected snapshot %+v", option)
+	}
+
+	sells, _ := journal.Entries(JournalQuery{Type: JournalSell, Limit: 10})
+	if len(sells) != 1 {
+		t.Fatalf("expected 1 sell, got %d", len(sells))
+	}
+}
+
+func TestJournalImportBoughtFile(t *testing.T) {
+	dir := t.TempDir()
+	journal, err := OpenJournal(filepath.Join(dir, "journal.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	boughtFile := filepath.Join(dir, "trade.json")
+	bought := `{"DOGEUSDT":{"symbol":"DOGEUSDT","orderId":7,"executedQuantity":20,"cummulativeQuoteQuantity":10}}`
+	if err := ioutil.WriteFile(boughtFile, []byte(bought), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []int{1, 0} {
+		count, err := journal.ImportBoughtFile(boughtFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != expected {
+			t.Fatalf("import %d: expected %d, got %d", i, expected, count)
+		}
+	}
+	entries, _ := journal.Entries(JournalQuery{})
+	if len(entries) != 1 || entries[0].OrderID != 7 || entries[0].Price != 0.5 || !entries[0].Imported {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
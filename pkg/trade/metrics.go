@@ -0,0 +1,228 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"strconv"
+	"time"
+)
+
+const metricsNamespace = "binance_bot"
+
+// the metrics are labelled by SystemOption.Name, so the bots of a process are told apart.
+var (
+	openPositions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "open_positions",
+		Help:      "Positions the bot holds.",
+	}, []string{"bot"})
+
+	realisedPnL = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "realised_pnl",
+		Help:      "Realised PnL of the sold positions with commissions, in MainCoin.",
+	}, []string{"bot"})
+
+	buysTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "buys_total",
+		Help:      "Positions bought, by reason: strategy, manual or signal.",
+	}, []string{"bot", "reason"})
+
+	sellsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "sells_total",
+		Help:      "Positions sold, by reason.",
+	}, []string{"bot", "reason"})
+
+	orderLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "order_latency_seconds",
+		Help:      "Latency of placing and cancelling orders, by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"bot", "endpoint"})
+
+	apiErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "api_errors_total",
+		Help:      "Failed exchange calls, by endpoint and binance error code, network for errors without a code.",
+	}, []string{"bot", "endpoint", "code"})
+
+	requestWeightUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_weight_used",
+		Help:      "Request weight used in the current minute.",
+	}, []string{"bot"})
+
+	ordersUsed = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "orders_used",
+		Help:      "Orders placed in the current 10 seconds.",
+	}, []string{"bot"})
+
+	priceStaleness = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "price_feed_staleness_seconds",
+		Help:      "Age of the newest price, from the stream or polling.",
+	}, []string{"bot"})
+
+	loopDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "loop_tick_duration_seconds",
+		Help:      "Duration of a tick of the bot loops: watch_buy, watch_sell, buy and sell.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"bot", "loop"})
+)
+
+// the reasons of the buys_total metric
+const (
+	buyReasonStrategy = "strategy"
+	buyReasonManual   = "manual"
+	buyReasonSignal   = "signal"
+)
+
+// label returns the short name of the reason for metrics
+func (s SellReason) label() string {
+	switch s {
+	case SellReasonForTakeProfit:
+		return "take_profit"
+	case SellReasonForStopLoss:
+		return "stop_loss"
+	case SellReasonForForceStopLoss:
+		return "force_stop_loss"
+	case SellReasonManual:
+		return "manual"
+	case SellReasonStrategy:
+		return "strategy"
+	case SellReasonSignal:
+		return "signal"
+	}
+	return "unknown"
+}
+
+func (t *Trade) metricsLabel() string {
+	return t.Option().SystemOption.Name
+}
+
+// observeLoop records the duration of a loop tick started at start.
+func (t *Trade) observeLoop(loop string, start time.Time) {
+	loopDuration.WithLabelValues(t.metricsLabel(), loop).Observe(time.Since(start).Seconds())
+}
+
+// updatePositionMetrics sets the open positions and the realised PnL.
+func (t *Trade) updatePositionMetrics() {
+	bot := t.metricsLabel()
+	t.boughtMutex.Lock()
+	openPositions.WithLabelValues(bot).Set(float64(len(t.boughtInfo)))
+	t.boughtMutex.Unlock()
+
+	t.pnl.mu.Lock()
+	realisedPnL.WithLabelValues(bot).Set(t.pnl.report.Total.PnL)
+	t.pnl.mu.Unlock()
+}
+
+// updatePriceStaleness sets the age of the newest price in the book.
+func (t *Trade) updatePriceStaleness() {
+	var newest time.Time
+	t.book.mu.RLock()
+	for _, pr := range t.book.prices {
+		if pr.Time.After(newest) {
+			newest = pr.Time
+		}
+	}
+	t.book.mu.RUnlock()
+	if !newest.IsZero() {
+		priceStaleness.WithLabelValues(t.metricsLabel()).Set(t.now().Sub(newest).Seconds())
+	}
+}
+
+// metricsExchange counts the errors of an Exchange and times its order calls.
+type metricsExchange struct {
+	Exchange
+	bot string
+}
+
+func newMetricsExchange(exchange Exchange, bot string) Exchange {
+	if m, ok := exchange.(*metricsExchange); ok {
+		exchange = m.Exchange
+	}
+	return &metricsExchange{Exchange: exchange, bot: bot}
+}
+
+func (m *metricsExchange) observe(endpoint string, err error) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+	code := "network"
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		code = strconv.FormatInt(apiErr.Code, 10)
+	}
+	apiErrors.WithLabelValues(m.bot, endpoint, code).Inc()
+}
+
+func (m *metricsExchange) timeOrder(endpoint string, start time.Time, err error) {
+	orderLatency.WithLabelValues(m.bot, endpoint).Observe(time.Since(start).Seconds())
+	m.observe(endpoint, err)
+}
+
+func (m *metricsExchange) ListPrices(ctx context.Context, symbol string) ([]*binance.SymbolPrice, error) {
+	res, err := m.Exchange.ListPrices(ctx, symbol)
+	m.observe("ListPrices", err)
+	return res, err
+}
+
+func (m *metricsExchange) BookTickers(ctx context.Context, symbol string) ([]*binance.BookTicker, error) {
+	res, err := m.Exchange.BookTickers(ctx, symbol)
+	m.observe("BookTickers", err)
+	return res, err
+}
+
+func (m *metricsExchange) ExchangeInfo(ctx context.Context) (*binance.ExchangeInfo, error) {
+	res, err := m.Exchange.ExchangeInfo(ctx)
+	m.observe("ExchangeInfo", err)
+	return res, err
+}
+
+func (m *metricsExchange) CreateOrder(ctx context.Context, req OrderRequest) (*binance.CreateOrderResponse, error) {
+	start := time.Now()
+	res, err := m.Exchange.CreateOrder(ctx, req)
+	m.timeOrder("CreateOrder", start, err)
+	return res, err
+}
+
+func (m *metricsExchange) GetOrder(ctx context.Context, symbol string, id int64) (*binance.Order, error) {
+	res, err := m.Exchange.GetOrder(ctx, symbol, id)
+	m.observe("GetOrder", err)
+	return res, err
+}
+
+func (m *metricsExchange) Balances(ctx context.Context) ([]binance.Balance, error) {
+	res, err := m.Exchange.Balances(ctx)
+	m.observe("Balances", err)
+	return res, err
+}
+
+func (m *metricsExchange) CreateOCO(ctx context.Context, req OCORequest) (*binance.CreateOCOResponse, error) {
+	start := time.Now()
+	res, err := m.Exchange.CreateOCO(ctx, req)
+	m.timeOrder("CreateOCO", start, err)
+	return res, err
+}
+
+func (m *metricsExchange) CancelOrder(ctx context.Context, symbol string, id int64) (*binance.CancelOrderResponse, error) {
+	start := time.Now()
+	res, err := m.Exchange.CancelOrder(ctx, symbol, id)
+	m.timeOrder("CancelOrder", start, err)
+	return res, err
+}
+
+func (m *metricsExchange) OrderFills(ctx context.Context, symbol string, id int64) ([]*binance.Fill, error) {
+	res, err := m.Exchange.OrderFills(ctx, symbol, id)
+	m.observe("OrderFills", err)
+	return res, err
+}
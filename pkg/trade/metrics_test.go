@@ -0,0 +1,52 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"testing"
+)
+
+type failingExchange struct {
+	Exchange
+	err error
+}
+
+func (f *failingExchange) CreateOrder(ctx context.Context, req OrderRequest) (*binance.CreateOrderResponse, error) {
+	return nil, f.err
+}
+
+func TestMetrics(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.03, 1.05)
+	r := newSimRunner(t, sim, testSellOption)
+	r.trade.option.SystemOption.Name = "metrics"
+	r.run(context.Background())
+	r.sold(t)
+
+	if v := testutil.ToFloat64(buysTotal.WithLabelValues("metrics", buyReasonStrategy)); v != 1 {
+		t.Fatalf("expected 1 strategy buy, got %v", v)
+	}
+	if v := testutil.ToFloat64(sellsTotal.WithLabelValues("metrics", "take_profit")); v != 1 {
+		t.Fatalf("expected 1 take profit sell, got %v", v)
+	}
+	if v := testutil.ToFloat64(openPositions.WithLabelValues("metrics")); v != 0 {
+		t.Fatalf("expected no open position, got %v", v)
+	}
+	if v := testutil.ToFloat64(realisedPnL.WithLabelValues("metrics")); v <= 0 {
+		t.Fatalf("expected the realised pnl positive, got %v", v)
+	}
+
+	m := newMetricsExchange(&failingExchange{Exchange: sim, err: &common.APIError{Code: -1013, Message: "Filter failure: LOT_SIZE"}}, "metrics")
+	m.CreateOrder(context.Background(), OrderRequest{Symbol: "DOGEUSDT"})
+	m = newMetricsExchange(&failingExchange{Exchange: sim, err: errors.New("connection reset")}, "metrics")
+	m.CreateOrder(context.Background(), OrderRequest{Symbol: "DOGEUSDT"})
+	if v := testutil.ToFloat64(apiErrors.WithLabelValues("metrics", "CreateOrder", "-1013")); v != 1 {
+		t.Fatalf("expected 1 filter failure, got %v", v)
+	}
+	if v := testutil.ToFloat64(apiErrors.WithLabelValues("metrics", "CreateOrder", "network")); v != 1 {
+		t.Fatalf("expected 1 network error, got %v", v)
+	}
+}
@@ -0,0 +1,207 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"time"
+)
+
+const (
+	defaultOCOStopLimitGap = 0.5
+
+	// ocoNearLeg the percent from a leg the price is near it, the OCO is queried every tick then.
+	ocoNearLeg = 0.5
+
+	// ocoReconcileInterval how often an OCO is queried while the price is far from its legs,
+	// it catches fills of wicks between ticks and OCOs cancelled outside the bot.
+	ocoReconcileInterval = time.Minute
+)
+
+// ocoCheck the last time the OCO of a symbol is queried
+type ocoCheck struct {
+	listID int64
+	at     time.Time
+}
+
+// placeOCO protects the position with an exchange-side OCO at its TP and SL,
+// the stop is the StopLoss, or the ForceStopLoss if StopLoss is not set.
+// With trailing take profit the limit leg rests one TrailingTakeProfit above TP,
+// or it would fill as soon as TP is reached and nothing would trail.
+func (t *Trade) placeOCO(ctx context.Context, info *BoughtInfo) error {
+	option := t.Option().SellOption
+	limitPrice, stopPrice, ok := ocoPrices(info, option)
+	if !ok {
+		return errors.New("OCO needs StopLoss or ForceStopLoss")
+	}
+	gap := option.OCOStopLimitGap
+	if gap == 0 {
+		gap = defaultOCOStopLimitGap
+	}
+
+	rules, err := t.SymbolRules(ctx, info.Symbol)
+	if err != nil {
+		return err
+	}
+
+	stopPrice = rules.RoundPrice(stopPrice)
+	req := OCORequest{
+		Symbol:         info.Symbol,
+		Side:           binance.SideTypeSell,
+		Quantity:       rules.RoundQuantity(info.Volume*0.999, false),
+		Price:          rules.RoundPrice(limitPrice),
+		StopPrice:      stopPrice,
+		StopLimitPrice: rules.RoundPrice(stopPrice * (1 - gap/100)),
+	}
+	for _, p := range []float64{req.Price, req.StopLimitPrice} {
+		if err := rules.Validate(req.Quantity, p, false); err != nil {
+			return err
+		}
+	}
+	resp, err := t.exchange.CreateOCO(ctx, req)
+	if err != nil {
+		t.filterFailed(err)
+		return err
+	}
+	info.OrderListID = resp.OrderListID
+	for _, o := range resp.OrderReports {
+		if o.Type == binance.OrderTypeStopLossLimit {
+			info.OCOStopOrderID = o.OrderID
+		} else {
+			info.OCOLimitOrderID = o.OrderID
+		}
+	}
+	if info.OCOLimitOrderID == 0 || info.OCOStopOrderID == 0 {
+		return fmt.Errorf("unexpected OCO response of %s: %d reports", info.Symbol, len(resp.OrderReports))
+	}
+	t.logger.Infof("placed OCO %d of %s", info.OrderListID, info.Symbol)
+	return nil
+}
+
+// ocoPrices returns the limit and stop prices of the OCO of the position, before rounding.
+// ok is false if the position has neither StopLoss nor ForceStopLoss.
+func ocoPrices(info *BoughtInfo, option SellOption) (limit, stop float64, ok bool) {
+	stopLoss := info.StopLoss
+	if stopLoss == nil {
+		stopLoss = info.ForceStopLoss
+	}
+	if stopLoss == nil {
+		return 0, 0, false
+	}
+	takeProfit := info.TakeProfit
+	if option.EnableTrailingTakeProfit {
+		takeProfit += option.TrailingTakeProfit
+	}
+	price := info.GetPrice()
+	return price * (1 + takeProfit/100), price * (1 + *stopLoss/100), true
+}
+
+// shouldReconcileOCO tells whether the OCO of the position is queried this tick. Querying every OCO
+// every tick would use most of the weight budget, so it is only queried while the price is near a leg,
+// and every ocoReconcileInterval otherwise.
+func (t *Trade) shouldReconcileOCO(info *BoughtInfo, sp *SymbolPrice, option SellOption) bool {
+	now := t.now()
+	t.ocoMutex.Lock()
+	defer t.ocoMutex.Unlock()
+
+	if sp != nil {
+		limit, stop, _ := ocoPrices(info, option)
+		if sp.Price >= limit*(1-ocoNearLeg/100) || sp.Price <= stop*(1+ocoNearLeg/100) {
+			t.ocoChecked[info.Symbol] = ocoCheck{listID: info.OrderListID, at: now}
+			return true
+		}
+	}
+	last, ok := t.ocoChecked[info.Symbol]
+	if ok && last.listID == info.OrderListID && now.Sub(last.at) < ocoReconcileInterval {
+		return false
+	}
+	t.ocoChecked[info.Symbol] = ocoCheck{listID: info.OrderListID, at: now}
+	return true
+}
+
+// reconcileOCO closes the position if a leg of its OCO filled, it returns whether the position is closed.
+// An OCO cancelled outside the bot is dropped, TP/SL are polled again.
+func (t *Trade) reconcileOCO(ctx context.Context, info *BoughtInfo) bool {
+	// both legs finish together, the stop leg is only queried once the limit leg is done.
+	legs := []struct {
+		id     int64
+		reason SellReason
+	}{
+		{info.OCOLimitOrderID, SellReasonForTakeProfit},
+		{info.OCOStopOrderID, SellReasonForStopLoss},
+	}
+	for _, leg := range legs {
+		order, err := t.GetOrder(ctx, info.Symbol, leg.id, 1)
+		if err != nil {
+			t.logger.WithError(err).Errorf("failed to query OCO order %d of %s", leg.id, info.Symbol)
+			return false
+		}
+		switch order.Status {
+		case binance.OrderStatusTypeNew, binance.OrderStatusTypePartiallyFilled:
+			return false
+		case binance.OrderStatusTypeFilled:
+			fills, err := t.exchange.OrderFills(ctx, info.Symbol, order.OrderID)
+			if err != nil {
+				t.logger.WithError(err).Errorf("failed to query fills of %s, commission is ignored", info.Symbol)
+			}
+			c := *info
+			return t.closePosition(ctx, info, &SellBill{Info: &c, Reason: leg.reason}, orderResponse(order, fills))
+		}
+	}
+
+	t.logger.Warnf("OCO %d of %s is cancelled outside the bot, TP/SL are polled", info.OrderListID, info.Symbol)
+	info.OrderListID, info.OCOLimitOrderID, info.OCOStopOrderID = 0, 0, 0
+	t.updateBoughtInfo(info)
+	t.save()
+	return false
+}
+
+// cancelOCO cancels the OCO of the position, the position is closed instead if the OCO filled meanwhile.
+func (t *Trade) cancelOCO(ctx context.Context, info *BoughtInfo) (closed bool, err error) {
+	if _, err := t.exchange.CancelOrder(ctx, info.Symbol, info.OCOLimitOrderID); err != nil {
+		if t.reconcileOCO(ctx, info) {
+			return true, nil
+		}
+		if info.OrderListID == 0 {
+			return false, nil
+		}
+		return false, err
+	}
+	info.OrderListID, info.OCOLimitOrderID, info.OCOStopOrderID = 0, 0, 0
+	t.updateBoughtInfo(info)
+	return false, nil
+}
+
+// amendOCO replaces the OCO with the current TP/SL of the position
+func (t *Trade) amendOCO(ctx context.Context, info *BoughtInfo) {
+	closed, err := t.cancelOCO(ctx, info)
+	if err == nil && !closed {
+		err = t.placeOCO(ctx, info)
+		t.updateBoughtInfo(info)
+		t.save()
+	}
+	if err != nil {
+		t.logger.WithError(err).Errorf("failed to amend OCO of %s, TP/SL are polled", info.Symbol)
+		t.emitError(info.Symbol, err)
+	}
+}
+
+// orderResponse converts a queried order to the response of creating it.
+func orderResponse(order *binance.Order, fills []*binance.Fill) *binance.CreateOrderResponse {
+	return &binance.CreateOrderResponse{
+		Symbol:                   order.Symbol,
+		OrderID:                  order.OrderID,
+		ClientOrderID:            order.ClientOrderID,
+		TransactTime:             order.UpdateTime,
+		Price:                    order.Price,
+		OrigQuantity:             order.OrigQuantity,
+		ExecutedQuantity:         order.ExecutedQuantity,
+		CummulativeQuoteQuantity: order.CummulativeQuoteQuantity,
+		Status:                   order.Status,
+		TimeInForce:              order.TimeInForce,
+		Type:                     order.Type,
+		Side:                     order.Side,
+		Fills:                    fills,
+	}
+}
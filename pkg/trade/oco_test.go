@@ -0,0 +1,142 @@
+package trade
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2"
+	"math"
+	"testing"
+	"time"
+)
+
+func ocoSellOption() SellOption {
+	option := testSellOption
+	option.OCO = true
+	return option
+}
+
+func TestSimOCOTakeProfit(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.05)
+	r := newSimRunner(t, sim, ocoSellOption())
+	r.run(context.Background())
+
+	bill := r.sold(t)
+	if bill.Reason != SellReasonForTakeProfit {
+		t.Fatalf("expected take profit, got %s", bill.Reason)
+	}
+	// filled by the limit leg at TP, not at the market price.
+	if want := 1.02 * 1.02; math.Abs(bill.PnL.SellPrice-want) > 1e-6 {
+		t.Fatalf("expected sell price %f, got %f", want, bill.PnL.SellPrice)
+	}
+	if len(r.trade.getBoughtInfo()) != 0 {
+		t.Fatal("expected the position closed")
+	}
+}
+
+func TestSimOCOTrailingTakeProfit(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.045, 1.07, 1.09, 1.06)
+	option := ocoSellOption()
+	option.EnableTrailingTakeProfit = true
+	option.TrailingTakeProfit = 2
+	option.TrailingStopLoss = 2
+	r := newSimRunner(t, sim, option)
+	r.run(context.Background())
+
+	// the limit leg rests above TP, so TP trails to 8% and the raised stop leg sells at 1.06.
+	bill := r.sold(t)
+	if bill.Reason != SellReasonForStopLoss {
+		t.Fatalf("expected stop loss, got %s", bill.Reason)
+	}
+	if bill.Info.TakeProfit != 8 {
+		t.Fatalf("expected take profit trailed to 8, got %f", bill.Info.TakeProfit)
+	}
+	if bill.PnL.SellPrice <= 1.02*1.02 {
+		t.Fatalf("expected to sell above the first TP, got %f", bill.PnL.SellPrice)
+	}
+}
+
+func TestSimOCOStopLoss(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 0.99)
+	r := newSimRunner(t, sim, ocoSellOption())
+	r.run(context.Background())
+
+	if bill := r.sold(t); bill.Reason != SellReasonForStopLoss {
+		t.Fatalf("expected stop loss, got %s", bill.Reason)
+	}
+}
+
+func TestSimOCOManualSellAndAmend(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02)
+	r := newSimRunner(t, sim, ocoSellOption())
+	r.run(context.Background())
+	ctx := context.Background()
+
+	info := r.trade.getBoughtInfo()["DOGEUSDT"]
+	if info == nil || info.OrderListID == 0 {
+		t.Fatalf("expected the position protected by OCO, got %+v", info)
+	}
+
+	// trailing raises TP, the OCO is replaced.
+	old := *info
+	info.TakeProfit += 1
+	r.trade.amendOCO(ctx, info)
+	amended := r.trade.getBoughtInfo()["DOGEUSDT"]
+	if amended.OrderListID == old.OrderListID {
+		t.Fatal("expected a new OCO")
+	}
+	if o, _ := sim.GetOrder(ctx, "DOGEUSDT", old.OCOLimitOrderID); o.Status != binance.OrderStatusTypeCanceled {
+		t.Fatalf("expected the old OCO cancelled, got %s", o.Status)
+	}
+	if o, _ := sim.GetOrder(ctx, "DOGEUSDT", amended.OCOLimitOrderID); o.Price != formatFloat(FloatTrunc(1.02*1.03, 6)) {
+		t.Fatalf("unexpected take profit price %s", o.Price)
+	}
+
+	if err := r.trade.SellNow(ctx, "DOGEUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	r.trade.sell(ctx, <-r.trade.sellChan)
+	if bill := r.sold(t); bill.Reason != SellReasonManual {
+		t.Fatalf("expected manual sell, got %s", bill.Reason)
+	}
+	if o, _ := sim.GetOrder(ctx, "DOGEUSDT", amended.OCOStopOrderID); o.Status != binance.OrderStatusTypeCanceled {
+		t.Fatalf("expected the OCO cancelled, got %s", o.Status)
+	}
+}
+
+func TestSimOCOReconcileThrottled(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02)
+	r := newSimRunner(t, sim, ocoSellOption())
+	ctx := context.Background()
+	r.run(ctx)
+
+	now := time.Now()
+	r.trade.clock = func() time.Time { return now }
+	c := &countingExchange{Exchange: sim}
+	r.trade.SetExchange(c)
+	option := r.trade.Option()
+	expect := func(price float64, queries int) {
+		t.Helper()
+		sim.SetPrice("DOGEUSDT", price)
+		c.getOrders = 0
+		if err := r.trade.checkingTPSL(ctx, option); err != nil {
+			t.Fatal(err)
+		}
+		if c.getOrders != queries {
+			t.Fatalf("expected %d queries at %f, got %d", queries, price, c.getOrders)
+		}
+	}
+
+	// TP 1.0404 and SL 1.0047 are far, the OCO was queried after buying.
+	expect(1.02, 0)
+	// near the limit leg it is queried every tick.
+	expect(1.037, 1)
+	expect(1.037, 1)
+	expect(1.02, 0)
+	now = now.Add(ocoReconcileInterval)
+	expect(1.02, 1)
+	expect(1.02, 0)
+}
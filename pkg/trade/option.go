@@ -24,7 +24,7 @@ type floatWrapper struct {
 
 var (
 	DefaultBuyOption = BuyOption{
-		Interval:              1,
+		Interval:              1 * time.Second,
 		PriceUpChange:         &(&floatWrapper{f: 1}).f,
 		MaxBuy:                4,
 		MoneyPerOrder:         11,
@@ -69,9 +69,13 @@ type BuyOption struct {
 	// MoneyPerOrder the total money we buy each order
 	MoneyPerOrder float64
 
-	// MainCoin is the money unit, like: USDT
+	// MainCoin is the money unit, like: USDT. PnL of the other quotes is converted to it.
 	MainCoin string
 
+	// Quotes the quote assets traded side by side, each with its own MoneyPerOrder and MaxBuy.
+	// default: MainCoin with MoneyPerOrder and MaxBuy
+	Quotes []QuoteOption
+
 	// WhiteList we will only buy the coins in the list
 	WhiteList []string
 
@@ -80,12 +84,43 @@ type BuyOption struct {
 
 	// SameCoinBlockDuration that means if we just sell a coin, after how long we can buy it again.
 	SameCoinBlockDuration time.Duration
+
+	// Strategy the name of the registered strategy deciding what to buy, default: momentum
+	Strategy string
+
+	// SignalMaxSize the max MainCoin an external signal may spend on a buy, default: MoneyPerOrder
+	SignalMaxSize float64
+
+	// EntryMode how we enter a position: market, limit or limit_maker, default: market
+	EntryMode string
+
+	// EntryOffset the percent of the limit price from the best bid, eg: 0.1 bids 0.1% above the bid
+	EntryOffset float64
+
+	// EntryTimeout how long a limit entry rests before the leftover is cancelled, default: 10s
+	EntryTimeout time.Duration
+
+	// EntryReprices how many times the leftover is placed again at the new bid after the timeout
+	EntryReprices int
+
+	// Universe buys any symbol trading against one of the Quotes on spot instead of the WhiteList,
+	// it is refreshed with the exchange info.
+	Universe bool
+
+	// Include only the symbols matching these patterns are bought, eg: "BTC", "*DOGE*". empty includes all.
+	Include []string
+
+	// Exclude the symbols matching these patterns are never bought, eg: "*UP", "*DOWN", "USDC".
+	// default with Universe: leveraged tokens, fiat and stablecoins. The WhiteList is not filtered by default.
+	Exclude []string
 }
 
 func (b BuyOption) InWhiteList(symbol string) bool {
-	for _, w := range b.WhiteList {
-		if symbol+b.MainCoin == w {
-			return true
+	for _, q := range b.quotes() {
+		for _, w := range b.WhiteList {
+			if symbol == w+q.Coin {
+				return true
+			}
 		}
 	}
 	return false
@@ -93,15 +128,15 @@ func (b BuyOption) InWhiteList(symbol string) bool {
 
 // SellOption defines sell option to sell a coin
 type SellOption struct {
-	// StopLoss once coin's price reach to the price, we will sell it
+	// StopLoss once coin's price falls this percent under the buy price, we will sell it, eg: 1.5 means -1.5%
 	// If we set ForceStopLoss, we will decide to wait for a moment.
 	StopLoss float64
 
 	// StopLossDuration  once the price reach to (ForceStopLoss, StopLoss]
-	// We will wait, once it reach to the time, we will decide to sell it.
+	// We will wait, once the position is held this long, we will decide to sell it.
 	StopLossDuration time.Duration
 
-	// TakeProfit once coin's price reach to the price, we will sell it
+	// TakeProfit once coin's price rises this percent over the buy price, we will sell it, eg: 2 means +2%
 	TakeProfit float64
 
 	EnableTrailingTakeProfit bool
@@ -119,6 +154,15 @@ type SellOption struct {
 
 	// ForceStopLoss
 	ForceStopLoss float64
+
+	// OCO places an exchange-side OCO (limit take profit + stop limit) after buying,
+	// so positions are protected while the bot is down. With EnableTrailingTakeProfit
+	// the limit leg rests one TrailingTakeProfit above TakeProfit and is moved up as TakeProfit trails.
+	// The stop leg triggers at once, StopLossDuration must be 0.
+	OCO bool
+
+	// OCOStopLimitGap the percent the stop limit price is below the stop price, default: 0.5
+	OCOStopLimitGap float64
 }
 
 // SystemOption defines the options for system to running
@@ -129,6 +173,91 @@ type SystemOption struct {
 	Debug     bool
 
 	ProxyURL string
+
+	// JournalFile the embedded database every order is recorded in, empty disables the journal.
+	JournalFile string
+
+	// APIToken the bearer token required by the http control endpoints, they are disabled if it is empty.
+	APIToken string
+
+	// SignalToken the token of the external signals endpoint only, it may be passed as ?token=
+	// by clients unable to set headers, eg: TradingView alerts. Signals are disabled if it is empty.
+	SignalToken string
+
+	// PriceStream reads prices from binance websocket streams instead of polling them every interval,
+	// polling is still used while the stream is down.
+	PriceStream bool
+
+	// PriceStreamURL the websocket base url, default: wss://stream.binance.com:9443
+	PriceStreamURL string
+
+	// PriceStreamStaleAfter the stream is treated as broken if no message arrived in it, default: 10s
+	PriceStreamStaleAfter time.Duration
+
+	// PaperTrading fills orders virtually at the current ticker price, prices are still read from binance.
+	// The positions and the journal are kept apart from the live ones, in BoughtFile.paper and JournalFile.paper.
+	PaperTrading bool
+
+	// PaperBalance the virtual MainCoin balance paper trading starts with,
+	// the other quotes start with MoneyPerOrder * MaxBuy
+	PaperBalance float64
+
+	// PaperFee the commission rate of every virtual fill, eg: 0.001
+	PaperFee float64
+
+	// PaperSlippage the rate virtual fills are worse than the ticker price, eg: 0.0005
+	PaperSlippage float64
+
+	// TelegramToken the token of the telegram bot posting notifications, empty disables it.
+	TelegramToken string
+
+	// TelegramChatID the only chat notified and allowed to send commands
+	TelegramChatID int64
+
+	// TelegramAPIURL the bot api base url, default: https://api.telegram.org
+	TelegramAPIURL string
+
+	// Webhooks the urls events are posted to
+	Webhooks []WebhookOption
+
+	// ExchangeInfoInterval how often the cached exchange info is refreshed, default: 1h
+	ExchangeInfoInterval time.Duration
+
+	// WeightLimit the request weight per minute the bot may use, default: 1200
+	WeightLimit int
+
+	// OrderLimit the orders per 10 seconds the bot may place, default: 50
+	OrderLimit int
+
+	// Name the bot name added to each log line, set by the bot manager.
+	Name string
+}
+
+// WebhookOption defines an url events are posted to as json
+type WebhookOption struct {
+	URL string
+
+	// Secret signs the body with HMAC-SHA256 in the X-Signature header, empty sends no signature.
+	Secret string
+
+	// Events the event types posted: buy, sell, trailing, error. empty posts all.
+	Events []EventType
+
+	// MaxRetries how many times a failed post is retried with backoff, default: 3
+	MaxRetries int
+}
+
+// Accept returns whether the event type should be posted
+func (w WebhookOption) Accept(e EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, v := range w.Events {
+		if v == e {
+			return true
+		}
+	}
+	return false
 }
 
 func WithSellOption(option SellOption) Options {
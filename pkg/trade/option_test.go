@@ -3,6 +3,7 @@ package trade
 import (
 	"github.com/ghodss/yaml"
 	"io/ioutil"
+	"path/filepath"
 	"testing"
 )
 
@@ -12,5 +13,5 @@ func TestWithDefaultSellOption(t *testing.T) {
 	o.SellOption = DefaultSellOption
 
 	data,_ := yaml.Marshal(o)
-	ioutil.WriteFile("config.yaml",data,0777)
+	ioutil.WriteFile(filepath.Join(t.TempDir(), "config.yaml"),data,0777)
 }
@@ -0,0 +1,146 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"strconv"
+	"sync"
+)
+
+// paperSuffix is added to BoughtFile and JournalFile in paper trading,
+// so the virtual positions are never read, sold or overwritten as live ones.
+const paperSuffix = ".paper"
+
+// boughtFile returns the file the positions are saved in
+func (o Option) boughtFile() string {
+	return paperFile(o.BuyOption.BoughtFile, o.SystemOption.PaperTrading)
+}
+
+// journalFile returns the file of the journal
+func (o Option) journalFile() string {
+	return paperFile(o.SystemOption.JournalFile, o.SystemOption.PaperTrading)
+}
+
+func paperFile(file string, paper bool) string {
+	if file == "" || !paper {
+		return file
+	}
+	return file + paperSuffix
+}
+
+// paperExchange reads prices and exchange info from the live exchange,
+// but fills orders on a SimExchange at the current ticker price.
+type paperExchange struct {
+	Exchange
+
+	sim *SimExchange
+
+	mu     sync.Mutex
+	listed map[string]bool
+}
+
+// NewPaperExchange returns an Exchange that never sends orders to live.
+func NewPaperExchange(live Exchange, sim *SimExchange) Exchange {
+	return &paperExchange{
+		Exchange: live,
+		sim:      sim,
+		listed:   make(map[string]bool),
+	}
+}
+
+func (p *paperExchange) CreateOrder(ctx context.Context, req OrderRequest) (*binance.CreateOrderResponse, error) {
+	if err := p.list(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+
+	if err := p.refresh(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+	return p.sim.CreateOrder(ctx, req)
+}
+
+func (p *paperExchange) CreateOCO(ctx context.Context, req OCORequest) (*binance.CreateOCOResponse, error) {
+	if err := p.list(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+	if err := p.refresh(ctx, req.Symbol); err != nil {
+		return nil, err
+	}
+	return p.sim.CreateOCO(ctx, req)
+}
+
+// GetOrder refreshes the price first, so resting orders are filled by the live price.
+func (p *paperExchange) GetOrder(ctx context.Context, symbol string, id int64) (*binance.Order, error) {
+	order, err := p.sim.GetOrder(ctx, symbol, id)
+	if err != nil || !order.IsWorking {
+		return order, err
+	}
+	if err := p.refresh(ctx, symbol); err != nil {
+		return nil, err
+	}
+	return p.sim.GetOrder(ctx, symbol, id)
+}
+
+func (p *paperExchange) CancelOrder(ctx context.Context, symbol string, id int64) (*binance.CancelOrderResponse, error) {
+	return p.sim.CancelOrder(ctx, symbol, id)
+}
+
+func (p *paperExchange) OrderFills(ctx context.Context, symbol string, id int64) ([]*binance.Fill, error) {
+	return p.sim.OrderFills(ctx, symbol, id)
+}
+
+// refresh sets the sim price of symbol to the live ticker price.
+func (p *paperExchange) refresh(ctx context.Context, symbol string) error {
+	prices, err := p.Exchange.ListPrices(ctx, symbol)
+	if err != nil {
+		return err
+	}
+	if len(prices) == 0 {
+		return fmt.Errorf("price not found: %s", symbol)
+	}
+	price, err := strconv.ParseFloat(prices[0].Price, 64)
+	if err != nil {
+		return err
+	}
+	p.sim.SetPrice(symbol, price)
+	return nil
+}
+
+func (p *paperExchange) Balances(ctx context.Context) ([]binance.Balance, error) {
+	return p.sim.Balances(ctx)
+}
+
+// list adds the symbol to the SimExchange with the live exchange info.
+func (p *paperExchange) list(ctx context.Context, symbol string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.listed[symbol] {
+		return nil
+	}
+	info, err := p.Exchange.ExchangeInfo(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range info.Symbols {
+		if s.Symbol != symbol {
+			continue
+		}
+		sym := SimSymbol{
+			Symbol:     s.Symbol,
+			BaseAsset:  s.BaseAsset,
+			QuoteAsset: s.QuoteAsset,
+		}
+		if f := s.LotSizeFilter(); f != nil {
+			sym.StepSize = f.StepSize
+		}
+		if f := s.PriceFilter(); f != nil {
+			sym.TickSize = f.TickSize
+		}
+		p.sim.AddSymbol(sym)
+		p.listed[symbol] = true
+		return nil
+	}
+	return fmt.Errorf("symbol not found: %s", symbol)
+}
@@ -0,0 +1,45 @@
+package trade
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPaperKeepsLivePositions(t *testing.T) {
+	dir := t.TempDir()
+	live := filepath.Join(dir, "trade.json")
+	bought := `{"DOGEUSDT":{"symbol":"DOGEUSDT","orderId":1,"takeProfit":2,"volume":20,"executedQuantity":20,"cummulativeQuoteQuantity":10}}`
+	if err := ioutil.WriteFile(live, []byte(bought), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := NewTrade(
+		WithBuyOption(BuyOption{Interval: time.Second, MaxBuy: 1, MoneyPerOrder: 11, MainCoin: "USDT", BoughtFile: live}),
+		WithSellOption(testSellOption),
+		WithSystemOption(SystemOption{PaperTrading: true, JournalFile: filepath.Join(dir, "journal.db")}),
+	)
+	defer tr.Close()
+
+	// the live position is neither loaded nor deposited to the virtual balance.
+	if len(tr.getBoughtInfo()) != 0 {
+		t.Fatal("expected no position in paper trading")
+	}
+	if tr.paper.Balance("DOGE") != 0 {
+		t.Fatalf("expected no DOGE deposited, got %f", tr.paper.Balance("DOGE"))
+	}
+
+	tr.boughtInfo["ETHUSDT"] = &BoughtInfo{Symbol: "ETHUSDT", OrderId: 2}
+	tr.save()
+	data, _ := ioutil.ReadFile(live)
+	if string(data) != bought {
+		t.Fatalf("expected the live positions untouched, got %s", data)
+	}
+	if _, err := ioutil.ReadFile(live + paperSuffix); err != nil {
+		t.Fatalf("expected the paper positions saved apart: %v", err)
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(dir, "journal.db"+paperSuffix)); err != nil {
+		t.Fatalf("expected the paper journal apart: %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package trade
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fillSummary sums the fills of an order, commissions are converted to the quote asset at fill time.
+type fillSummary struct {
+	Quantity   float64
+	Quote      float64
+	Price      float64
+	Commission float64
+
+	// BaseCommission the commission paid in the base asset, we receive less coins than executed.
+	BaseCommission float64
+}
+
+func (t *Trade) summarizeFills(ctx context.Context, resp *binance.CreateOrderResponse, base, quote string) fillSummary {
+	var s fillSummary
+	s.Quantity, _ = strconv.ParseFloat(resp.ExecutedQuantity, 64)
+	s.Quote, _ = strconv.ParseFloat(resp.CummulativeQuoteQuantity, 64)
+	if s.Quantity != 0 {
+		s.Price = s.Quote / s.Quantity
+	}
+
+	for _, f := range resp.Fills {
+		commission, _ := strconv.ParseFloat(f.Commission, 64)
+		if commission == 0 {
+			continue
+		}
+		switch f.CommissionAsset {
+		case quote:
+			s.Commission += commission
+		case base:
+			price, _ := strconv.ParseFloat(f.Price, 64)
+			s.Commission += commission * price
+			s.BaseCommission += commission
+		default:
+			// eg: BNB, convert with its price now.
+			s.Commission += commission * t.assetPrice(ctx, f.CommissionAsset, quote)
+		}
+	}
+	return s
+}
+
+// assetPrice returns the price of asset in quote, 0 if no market found.
+func (t *Trade) assetPrice(ctx context.Context, asset, quote string) float64 {
+	if pr, ok := t.GetSymbolPrice(ctx, asset+quote)[asset+quote]; ok {
+		return pr.Price
+	}
+	if pr, ok := t.GetSymbolPrice(ctx, quote+asset)[quote+asset]; ok && pr.Price != 0 {
+		return 1 / pr.Price
+	}
+	t.logger.Warnf("no market to convert %s to %s", asset, quote)
+	return 0
+}
+
+// TradePnL defines what a closed position earned, commissions of both orders included.
+type TradePnL struct {
+	Symbol      string    `json:"symbol"`
+	BuyOrderID  int64     `json:"buyOrderId"`
+	SellOrderID int64     `json:"sellOrderId"`
+	BuyTime     time.Time `json:"buyTime"`
+	SellTime    time.Time `json:"sellTime"`
+	BuyPrice    float64   `json:"buyPrice"`
+	SellPrice   float64   `json:"sellPrice"`
+	Quantity    float64   `json:"quantity"`
+
+	// Cost the quote spent on the position, the coins paid as commission are in Commission. Proceeds the quote received.
+	Cost       float64 `json:"cost"`
+	Proceeds   float64 `json:"proceeds"`
+	Commission float64 `json:"commission"`
+	PnL        float64 `json:"pnl"`
+	PnLPercent float64 `json:"pnlPercent"`
+
+	// Dust the part of Cost spent on the coins left unsold, eg: below the lot step size.
+	Dust float64 `json:"dust,omitempty"`
+
+	// QuoteAsset the asset Cost, Proceeds, Commission and PnL are in.
+	QuoteAsset string `json:"quoteAsset,omitempty"`
+
+	// ReportAsset the MainCoin at sell time, ReportRate the price of QuoteAsset in it, 0 if no market found.
+	ReportAsset string  `json:"reportAsset,omitempty"`
+	ReportRate  float64 `json:"reportRate,omitempty"`
+}
+
+// reportRate returns the rate converting to the reporting currency,
+// trades recorded before the quote is known are in it already.
+func (p *TradePnL) reportRate() float64 {
+	if p.QuoteAsset == "" {
+		return 1
+	}
+	return p.ReportRate
+}
+
+// tradePnL the position is closed by the sell, the cost of the coins left unsold (dust) is charged too.
+func tradePnL(info *BoughtInfo, sellOrderID int64, sellTime time.Time, sold fillSummary) *TradePnL {
+	p := &TradePnL{
+		Symbol:      info.Symbol,
+		BuyOrderID:  info.OrderId,
+		SellOrderID: sellOrderID,
+		BuyTime:     info.Time,
+		SellTime:    sellTime,
+		SellPrice:   sold.Price,
+		Quantity:    sold.Quantity,
+		Proceeds:    sold.Quote,
+	}
+	if info.ExecutedQuantity == 0 {
+		return p
+	}
+	p.BuyPrice = info.GetPrice()
+	p.Cost = info.CummulativeQuoteQuantity - info.BaseCommission*p.BuyPrice
+	if left := info.ExecutedQuantity - info.BaseCommission - sold.Quantity; left > 0 {
+		p.Dust = left * p.BuyPrice
+	}
+	p.Commission = info.Commission + sold.Commission
+	p.PnL = p.Proceeds - p.Cost - p.Commission
+	if p.Cost != 0 {
+		p.PnLPercent = p.PnL / p.Cost * 100
+	}
+	return p
+}
+
+// PnLSummary defines the realised PnL of a group of trades
+type PnLSummary struct {
+	Trades     int     `json:"trades"`
+	Wins       int     `json:"wins"`
+	PnL        float64 `json:"pnl"`
+	Commission float64 `json:"commission"`
+}
+
+// add sums the trade, its PnL and commission multiplied by rate.
+func (s *PnLSummary) add(p *TradePnL, rate float64) {
+	s.Trades++
+	if p.PnL > 0 {
+		s.Wins++
+	}
+	s.PnL += p.PnL * rate
+	s.Commission += p.Commission * rate
+}
+
+// PnLReport defines the realised PnL in total, per quote, per symbol and per day (UTC).
+// Total and Days are converted to Asset at sell time, Quotes and Symbols are in their quote asset.
+type PnLReport struct {
+	Asset   string                 `json:"asset"`
+	Total   PnLSummary             `json:"total"`
+	Quotes  map[string]*PnLSummary `json:"quotes"`
+	Symbols map[string]*PnLSummary `json:"symbols"`
+	Days    map[string]*PnLSummary `json:"days"`
+	Recent  []*TradePnL            `json:"recent"`
+}
+
+// pnlBook accumulates the realised PnL of the trade
+type pnlBook struct {
+	mu     sync.Mutex
+	report PnLReport
+}
+
+func newPnLBook() *pnlBook {
+	return &pnlBook{report: PnLReport{
+		Quotes:  make(map[string]*PnLSummary),
+		Symbols: make(map[string]*PnLSummary),
+		Days:    make(map[string]*PnLSummary),
+	}}
+}
+
+// add accumulates the trade, quote is used for the trades recorded without their quote asset.
+func (b *pnlBook) add(p *TradePnL, quote string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rate := p.reportRate()
+	b.report.Total.add(p, rate)
+	if p.QuoteAsset != "" {
+		quote = p.QuoteAsset
+	}
+	q, ok := b.report.Quotes[quote]
+	if !ok {
+		q = new(PnLSummary)
+		b.report.Quotes[quote] = q
+	}
+	q.add(p, 1)
+	sym, ok := b.report.Symbols[p.Symbol]
+	if !ok {
+		sym = new(PnLSummary)
+		b.report.Symbols[p.Symbol] = sym
+	}
+	sym.add(p, 1)
+	day := p.SellTime.UTC().Format("2006-01-02")
+	d, ok := b.report.Days[day]
+	if !ok {
+		d = new(PnLSummary)
+		b.report.Days[day] = d
+	}
+	d.add(p, rate)
+
+	b.report.Recent = append(b.report.Recent, p)
+	if len(b.report.Recent) > maxSellHistory {
+		b.report.Recent = b.report.Recent[len(b.report.Recent)-maxSellHistory:]
+	}
+}
+
+// PnL returns the realised PnL, including the journal if JournalFile is set.
+func (t *Trade) PnL() PnLReport {
+	t.pnl.mu.Lock()
+	defer t.pnl.mu.Unlock()
+
+	report := PnLReport{
+		Asset:   t.Option().BuyOption.MainCoin,
+		Total:   t.pnl.report.Total,
+		Quotes:  make(map[string]*PnLSummary),
+		Symbols: make(map[string]*PnLSummary),
+		Days:    make(map[string]*PnLSummary),
+	}
+	for k, v := range t.pnl.report.Quotes {
+		s := *v
+		report.Quotes[k] = &s
+	}
+	for k, v := range t.pnl.report.Symbols {
+		s := *v
+		report.Symbols[k] = &s
+	}
+	for k, v := range t.pnl.report.Days {
+		s := *v
+		report.Days[k] = &s
+	}
+	for i := len(t.pnl.report.Recent) - 1; i >= 0; i-- {
+		report.Recent = append(report.Recent, t.pnl.report.Recent[i])
+	}
+	return report
+}
+
+// loadPnL restores the realised PnL from the journal.
+func (t *Trade) loadPnL() error {
+	entries, err := t.journal.Entries(JournalQuery{Type: JournalSell})
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	mainCoin := t.Option().BuyOption.MainCoin
+	for _, e := range entries {
+		if e.PnL == nil {
+			continue
+		}
+		t.pnl.add(e.PnL, mainCoin)
+	}
+	return nil
+}
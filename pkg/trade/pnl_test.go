@@ -0,0 +1,120 @@
+package trade
+
+import (
+	"context"
+	"github.com/adshao/go-binance/v2"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeFills(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("BNBUSDT", 400)
+	r := newSimRunner(t, sim, testSellOption)
+
+	resp := &binance.CreateOrderResponse{
+		ExecutedQuantity:         "100",
+		CummulativeQuoteQuantity: "50",
+		Fills: []*binance.Fill{
+			{Price: "0.5", Quantity: "50", Commission: "0.025", CommissionAsset: "USDT"},
+			{Price: "0.5", Quantity: "30", Commission: "0.03", CommissionAsset: "DOGE"},
+			{Price: "0.5", Quantity: "20", Commission: "0.0001", CommissionAsset: "BNB"},
+		},
+	}
+	s := r.trade.summarizeFills(context.Background(), resp, "DOGE", "USDT")
+	if s.Price != 0.5 || s.BaseCommission != 0.03 {
+		t.Fatalf("unexpected summary %+v", s)
+	}
+	if want := 0.025 + 0.03*0.5 + 0.0001*400; math.Abs(s.Commission-want) > 1e-9 {
+		t.Fatalf("expected commission %f, got %f", want, s.Commission)
+	}
+}
+
+func TestSimRealisedPnL(t *testing.T) {
+	sim := newTestSim()
+	sim.Fee = 0.001
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.05)
+	r := newSimRunner(t, sim, testSellOption)
+	r.run(context.Background())
+
+	bill := r.sold(t)
+	if bill.PnL == nil {
+		t.Fatal("expected the pnl of the sell")
+	}
+	p := bill.PnL
+	if p.BuyPrice != 1.02 || p.SellPrice != 1.05 {
+		t.Fatalf("unexpected prices %+v", p)
+	}
+	// the coins below the lot step size are left unsold, their cost is charged as dust.
+	bought := bill.Info.ExecutedQuantity
+	if p.Quantity >= bought {
+		t.Fatalf("expected dust left unsold, bought %f sold %f", bought, p.Quantity)
+	}
+	cost := bought * 1.02
+	dust := (bought - p.Quantity) * 1.02
+	proceeds := p.Quantity * 1.05
+	commission := cost*0.001 + proceeds*0.001
+	if math.Abs(p.Cost-cost) > 1e-9 || math.Abs(p.Dust-dust) > 1e-9 {
+		t.Fatalf("unexpected cost %+v", p)
+	}
+	if math.Abs(p.Commission-commission) > 1e-9 || math.Abs(p.PnL-(proceeds-cost-commission)) > 1e-9 {
+		t.Fatalf("unexpected pnl %+v", p)
+	}
+
+	report := r.trade.PnL()
+	if report.Total.Trades != 1 || report.Total.Wins != 1 || report.Total.PnL != p.PnL {
+		t.Fatalf("unexpected total %+v", report.Total)
+	}
+	if sym := report.Symbols["DOGEUSDT"]; sym == nil || sym.PnL != p.PnL {
+		t.Fatalf("unexpected symbols %+v", report.Symbols)
+	}
+	if len(report.Days) != 1 || len(report.Recent) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+// baseFeeExchange charges the commission of buy orders in the base asset, like binance without BNB.
+type baseFeeExchange struct {
+	Exchange
+	fee float64
+}
+
+func (e *baseFeeExchange) CreateOrder(ctx context.Context, req OrderRequest) (*binance.CreateOrderResponse, error) {
+	resp, err := e.Exchange.CreateOrder(ctx, req)
+	if err != nil || req.Side != binance.SideTypeBuy {
+		return resp, err
+	}
+	for _, f := range resp.Fills {
+		quantity, _ := strconv.ParseFloat(f.Quantity, 64)
+		f.Commission = formatFloat(quantity * e.fee)
+		f.CommissionAsset = strings.TrimSuffix(req.Symbol, "USDT")
+	}
+	return resp, nil
+}
+
+func TestSimRealisedPnLBaseCommission(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.05)
+	r := newSimRunner(t, sim, testSellOption)
+	r.trade.SetExchange(&baseFeeExchange{Exchange: sim, fee: 0.001})
+	r.run(context.Background())
+
+	bill := r.sold(t)
+	p := bill.PnL
+	bought := bill.Info.ExecutedQuantity
+	fee := bought * 0.001
+	if bill.Info.BaseCommission != fee {
+		t.Fatalf("expected base commission %f, got %f", fee, bill.Info.BaseCommission)
+	}
+	// the coins paid as commission are charged once, in Commission, and are not dust.
+	cost := (bought - fee) * 1.02
+	dust := (bought - fee - p.Quantity) * 1.02
+	if math.Abs(p.Cost-cost) > 1e-9 || math.Abs(p.Dust-dust) > 1e-9 || math.Abs(p.Commission-fee*1.02) > 1e-9 {
+		t.Fatalf("unexpected cost %+v", p)
+	}
+	if want := p.Quantity*1.05 - bought*1.02; math.Abs(p.PnL-want) > 1e-9 {
+		t.Fatalf("expected pnl %f, got %+v", want, p)
+	}
+}
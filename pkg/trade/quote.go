@@ -0,0 +1,81 @@
+package trade
+
+import (
+	"context"
+	"strings"
+)
+
+// QuoteOption defines the budget of a quote asset we buy coins with
+type QuoteOption struct {
+	// Coin the quote asset, like: USDT, BUSD, BTC
+	Coin string
+
+	// MoneyPerOrder the Coin we spend each order
+	MoneyPerOrder float64
+
+	// MaxBuy max positions we hold bought with Coin
+	MaxBuy int
+
+	// SignalMaxSize the max Coin an external signal may spend on a buy, default: MoneyPerOrder
+	SignalMaxSize float64
+}
+
+// quotes returns the quote assets we trade, default: MainCoin with MoneyPerOrder and MaxBuy.
+func (b BuyOption) quotes() []QuoteOption {
+	if len(b.Quotes) > 0 {
+		return b.Quotes
+	}
+	return []QuoteOption{{
+		Coin:          b.MainCoin,
+		MoneyPerOrder: b.MoneyPerOrder,
+		MaxBuy:        b.MaxBuy,
+		SignalMaxSize: b.SignalMaxSize,
+	}}
+}
+
+// quote returns the option of the quote asset.
+func (b BuyOption) quote(coin string) (QuoteOption, bool) {
+	for _, q := range b.quotes() {
+		if q.Coin == coin {
+			return q, true
+		}
+	}
+	return QuoteOption{}, false
+}
+
+// quoteOf returns the option of the quote the symbol trades against, the longest matching suffix wins,
+// eg: FDUSD before USD.
+func (b BuyOption) quoteOf(symbol string) (QuoteOption, bool) {
+	var (
+		res   QuoteOption
+		found bool
+	)
+	for _, q := range b.quotes() {
+		if q.Coin != "" && len(q.Coin) < len(symbol) && strings.HasSuffix(symbol, q.Coin) && len(q.Coin) > len(res.Coin) {
+			res, found = q, true
+		}
+	}
+	return res, found
+}
+
+// symbolQuote returns the quote option of the symbol, the exchange info is used if it is loaded.
+func (t *Trade) symbolQuote(symbol string) (QuoteOption, bool) {
+	option := t.Option().BuyOption
+	c := t.exchangeInfo
+	c.mu.RLock()
+	s, ok := c.symbols[symbol]
+	c.mu.RUnlock()
+	if ok {
+		return option.quote(s.QuoteAsset)
+	}
+	return option.quoteOf(symbol)
+}
+
+// reportRate returns the price of the quote asset in the MainCoin PnL is reported in, 0 if no market found.
+func (t *Trade) reportRate(ctx context.Context, quote string) float64 {
+	report := t.Option().BuyOption.MainCoin
+	if quote == report {
+		return 1
+	}
+	return t.assetPrice(ctx, quote, report)
+}
@@ -0,0 +1,102 @@
+package trade
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestQuoteOf(t *testing.T) {
+	option := BuyOption{MainCoin: "USDT", MoneyPerOrder: 11, MaxBuy: 2}
+	if q, ok := option.quoteOf("DOGEUSDT"); !ok || q.Coin != "USDT" || q.MoneyPerOrder != 11 || q.MaxBuy != 2 {
+		t.Fatalf("expected the MainCoin budget, got %+v", q)
+	}
+	if _, ok := option.quoteOf("ETHBTC"); ok {
+		t.Fatal("ETHBTC is not quoted in USDT")
+	}
+
+	option.Quotes = []QuoteOption{
+		{Coin: "USD", MoneyPerOrder: 10, MaxBuy: 1},
+		{Coin: "FDUSD", MoneyPerOrder: 20, MaxBuy: 1},
+		{Coin: "BTC", MoneyPerOrder: 0.001, MaxBuy: 1},
+	}
+	if q, ok := option.quoteOf("DOGEFDUSD"); !ok || q.Coin != "FDUSD" {
+		t.Fatalf("expected the longest quote FDUSD, got %+v", q)
+	}
+	if q, ok := option.quoteOf("ETHBTC"); !ok || q.Coin != "BTC" {
+		t.Fatalf("expected BTC, got %+v", q)
+	}
+	if _, ok := option.quoteOf("DOGEUSDT"); ok {
+		t.Fatal("USDT is not one of the quotes")
+	}
+	option.WhiteList = []string{"ETH"}
+	if !option.InWhiteList("ETHBTC") || !option.InWhiteList("ETHFDUSD") || option.InWhiteList("ETHUSDT") {
+		t.Fatal("the white list should be combined with every quote")
+	}
+}
+
+func TestSimMultipleQuotes(t *testing.T) {
+	sim := NewSimExchange(map[string]float64{"USDT": 100, "BTC": 1})
+	sim.AddSymbol(SimSymbol{Symbol: "DOGEUSDT", BaseAsset: "DOGE", QuoteAsset: "USDT"})
+	sim.AddSymbol(SimSymbol{Symbol: "ETHUSDT", BaseAsset: "ETH", QuoteAsset: "USDT", StepSize: "0.00010000"})
+	sim.AddSymbol(SimSymbol{Symbol: "DOGEBTC", BaseAsset: "DOGE", QuoteAsset: "BTC", TickSize: "0.00000001", MinNotional: "0.00010000"})
+	sim.AddSymbol(SimSymbol{Symbol: "ETHBTC", BaseAsset: "ETH", QuoteAsset: "BTC", StepSize: "0.00010000", TickSize: "0.00000001", MinNotional: "0.00010000"})
+	sim.AddSymbol(SimSymbol{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT", StepSize: "0.00001000"})
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.05)
+	sim.SetPricePath("ETHUSDT", 2000, 2030, 2100)
+	sim.SetPricePath("DOGEBTC", 0.00002, 0.0000204, 0.000021)
+	sim.SetPricePath("ETHBTC", 0.04, 0.0406, 0.042)
+	sim.SetPrice("BTCUSDT", 50000)
+
+	r := newSimRunner(t, sim, testSellOption)
+	option := r.trade.Option()
+	option.BuyOption.WhiteList = []string{"DOGE", "ETH"}
+	option.BuyOption.Quotes = []QuoteOption{
+		{Coin: "USDT", MoneyPerOrder: 11, MaxBuy: 1},
+		{Coin: "BTC", MoneyPerOrder: 0.001, MaxBuy: 1},
+	}
+	r.trade.option = option
+
+	// one position per quote, the biggest changes first.
+	ctx := context.Background()
+	r.trade.checkingPrice(ctx, option)
+	sim.Step()
+	r.trade.buy(ctx, r.trade.checkingPrice(ctx, option))
+	positions := r.trade.getBoughtInfo()
+	if len(positions) != 2 || positions["DOGEUSDT"] == nil || positions["DOGEBTC"] == nil {
+		t.Fatalf("expected DOGEUSDT and DOGEBTC, got %v", positions)
+	}
+	if q := positions["DOGEBTC"].QuoteAsset; q != "BTC" {
+		t.Fatalf("expected quote BTC, got %s", q)
+	}
+	if cost := positions["DOGEBTC"].CummulativeQuoteQuantity; cost > 0.001 || cost < 0.0009 {
+		t.Fatalf("expected about 0.001 BTC spent, got %v", cost)
+	}
+
+	sim.Step()
+	if err := r.trade.checkingTPSL(ctx, option); err != nil {
+		t.Fatal(err)
+	}
+	for len(r.trade.sellChan) > 0 {
+		r.trade.sell(ctx, <-r.trade.sellChan)
+	}
+	r.sold(t)
+	r.sold(t)
+
+	report := r.trade.PnL()
+	usdt, btc := report.Quotes["USDT"], report.Quotes["BTC"]
+	if report.Asset != "USDT" || usdt == nil || btc == nil || usdt.Trades != 1 || btc.Trades != 1 {
+		t.Fatalf("unexpected quotes %+v", report.Quotes)
+	}
+	if btc.PnL <= 0 || btc.PnL > 0.0001 {
+		t.Fatalf("expected the BTC pnl in BTC, got %v", btc.PnL)
+	}
+	if want := usdt.PnL + btc.PnL*50000; math.Abs(report.Total.PnL-want) > 1e-9 || report.Total.Trades != 2 {
+		t.Fatalf("expected total pnl %v USDT, got %+v", want, report.Total)
+	}
+	day := report.Days[time.Now().UTC().Format("2006-01-02")]
+	if day == nil || math.Abs(day.PnL-report.Total.PnL) > 1e-9 {
+		t.Fatalf("expected the day pnl in USDT, got %+v", day)
+	}
+}
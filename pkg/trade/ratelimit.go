@@ -0,0 +1,235 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultWeightLimit = 1200
+	DefaultOrderLimit  = 50
+
+	weightWindow = time.Minute
+	orderWindow  = 10 * time.Second
+
+	// defaultBanDuration the back off of a 429/418 without Retry-After
+	defaultBanDuration = time.Minute
+)
+
+// Priority defines which requests keep going when the weight budget runs low.
+type Priority int
+
+const (
+	PriorityPoll Priority = iota
+	PriorityBuy
+	PrioritySell
+)
+
+// share the part of the weight limit requests of the priority may use,
+// the rest is kept for the higher priorities.
+func (p Priority) share() float64 {
+	switch p {
+	case PrioritySell:
+		return 1
+	case PriorityBuy:
+		return 0.9
+	}
+	return 0.7
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context the requests made with are limited at priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
+
+// RateLimiter budgets the request weight per minute and the orders per 10s of binance,
+// both are corrected by the X-MBX-USED-WEIGHT-1M and X-MBX-ORDER-COUNT-10S response headers.
+// A 429 or 418 stops every request until its Retry-After.
+type RateLimiter struct {
+	mu sync.Mutex
+
+	weightLimit int
+	orderLimit  int
+
+	weight       int
+	weightWindow time.Time
+	orders       int
+	orderWindow  time.Time
+
+	bannedUntil time.Time
+
+	now func() time.Time
+
+	// observe is told the used weight and orders after each response, eg: to export them.
+	observe func(weight, orders int)
+}
+
+// NewRateLimiter returns a RateLimiter, zero limits take binance's defaults.
+func NewRateLimiter(weightLimit, orderLimit int) *RateLimiter {
+	if weightLimit <= 0 {
+		weightLimit = DefaultWeightLimit
+	}
+	if orderLimit <= 0 {
+		orderLimit = DefaultOrderLimit
+	}
+	return &RateLimiter{
+		weightLimit: weightLimit,
+		orderLimit:  orderLimit,
+		now:         time.Now,
+	}
+}
+
+// Wait blocks until a request of weight, which places an order if order is true, fits in the budget of priority.
+func (l *RateLimiter) Wait(ctx context.Context, weight int, order bool, priority Priority) error {
+	for {
+		delay := l.reserve(weight, order, priority)
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes the weight from the budget, or returns how long to wait before trying again.
+func (l *RateLimiter) reserve(weight int, order bool, priority Priority) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.bannedUntil) {
+		return l.bannedUntil.Sub(now)
+	}
+	l.rollLocked(now)
+
+	if float64(l.weight+weight) > float64(l.weightLimit)*priority.share() {
+		return l.weightWindow.Add(weightWindow).Sub(now)
+	}
+	if order && l.orders+1 > l.orderLimit {
+		return l.orderWindow.Add(orderWindow).Sub(now)
+	}
+	l.weight += weight
+	if order {
+		l.orders++
+	}
+	return 0
+}
+
+// rollLocked starts new windows, binance counts them from the start of each minute and 10s.
+func (l *RateLimiter) rollLocked(now time.Time) {
+	if w := now.Truncate(weightWindow); w.After(l.weightWindow) {
+		l.weightWindow = w
+		l.weight = 0
+	}
+	if w := now.Truncate(orderWindow); w.After(l.orderWindow) {
+		l.orderWindow = w
+		l.orders = 0
+	}
+}
+
+// Update corrects the budget with the headers of the response, and backs off on 429 and 418.
+func (l *RateLimiter) Update(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.rollLocked(now)
+	if used, err := strconv.Atoi(resp.Header.Get("X-MBX-USED-WEIGHT-1M")); err == nil {
+		l.weight = used
+	}
+	if count, err := strconv.Atoi(resp.Header.Get("X-MBX-ORDER-COUNT-10S")); err == nil {
+		l.orders = count
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		ban := defaultBanDuration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			ban = time.Duration(seconds) * time.Second
+		}
+		if until := now.Add(ban); until.After(l.bannedUntil) {
+			l.bannedUntil = until
+		}
+	}
+	if l.observe != nil {
+		l.observe(l.weight, l.orders)
+	}
+}
+
+// Used returns the weight and orders used in the current windows.
+func (l *RateLimiter) Used() (weight int, orders int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rollLocked(l.now())
+	return l.weight, l.orders
+}
+
+// Transport returns a RoundTripper limiting the requests sent by next.
+func (l *RateLimiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &limitedTransport{next: next, limiter: l}
+}
+
+type limitedTransport struct {
+	next    http.RoundTripper
+	limiter *RateLimiter
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	weight, order := requestWeight(req)
+	if err := t.limiter.Wait(req.Context(), weight, order, priorityFrom(req.Context())); err != nil {
+		return nil, fmt.Errorf("rate limited: %w", err)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.limiter.Update(resp)
+	return resp, nil
+}
+
+// requestWeight returns the weight of the binance api the request calls, and whether it places an order.
+// The weights are binance's as of the spot api docs:
+// https://developers.binance.com/docs/binance-spot-api-docs/rest-api
+func requestWeight(req *http.Request) (int, bool) {
+	hasSymbol := req.URL.Query().Get("symbol") != ""
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/order/oco"):
+		return 1, req.Method == http.MethodPost
+	case strings.HasSuffix(path, "/order"):
+		if req.Method == http.MethodGet {
+			return 4, false
+		}
+		return 1, req.Method == http.MethodPost
+	case strings.HasSuffix(path, "/ticker/price"), strings.HasSuffix(path, "/ticker/bookTicker"):
+		if hasSymbol {
+			return 2, false
+		}
+		return 4, false
+	case strings.HasSuffix(path, "/exchangeInfo"):
+		return 20, false
+	case strings.HasSuffix(path, "/account"), strings.HasSuffix(path, "/myTrades"):
+		return 20, false
+	}
+	return 1, false
+}
@@ -0,0 +1,121 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterPriority(t *testing.T) {
+	now := time.Date(2021, 5, 1, 0, 0, 30, 0, time.UTC)
+	l := NewRateLimiter(100, 2)
+	l.now = func() time.Time { return now }
+
+	if d := l.reserve(60, false, PriorityPoll); d != 0 {
+		t.Fatalf("expected the poll allowed, got %s", d)
+	}
+	// polls may use 70% of the budget, buys 90% and sells all of it.
+	if d := l.reserve(15, false, PriorityPoll); d != 30*time.Second {
+		t.Fatalf("expected the poll to wait for the next minute, got %s", d)
+	}
+	if d := l.reserve(15, false, PriorityBuy); d != 0 {
+		t.Fatalf("expected the buy allowed, got %s", d)
+	}
+	if d := l.reserve(25, false, PrioritySell); d != 0 {
+		t.Fatalf("expected the sell allowed, got %s", d)
+	}
+	if d := l.reserve(1, false, PrioritySell); d == 0 {
+		t.Fatal("expected the budget used up")
+	}
+
+	now = now.Add(30 * time.Second)
+	if d := l.reserve(1, true, PrioritySell); d != 0 {
+		t.Fatalf("expected a new minute, got %s", d)
+	}
+	l.reserve(1, true, PrioritySell)
+	if d := l.reserve(1, true, PrioritySell); d != 10*time.Second {
+		t.Fatalf("expected the order limit reached, got %s", d)
+	}
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	now := time.Date(2021, 5, 1, 0, 0, 30, 0, time.UTC)
+	l := NewRateLimiter(100, 0)
+	l.now = func() time.Time { return now }
+
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-MBX-USED-WEIGHT-1M", "95")
+	resp.Header.Set("X-MBX-ORDER-COUNT-10S", "3")
+	l.Update(resp)
+	if weight, orders := l.Used(); weight != 95 || orders != 3 {
+		t.Fatalf("expected the used weight from headers, got %d %d", weight, orders)
+	}
+	if d := l.reserve(10, false, PrioritySell); d == 0 {
+		t.Fatal("expected the budget used up by the headers")
+	}
+
+	resp = &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "120")
+	l.Update(resp)
+	now = now.Add(time.Minute)
+	if d := l.reserve(1, false, PrioritySell); d != time.Minute {
+		t.Fatalf("expected to back off until Retry-After, got %s", d)
+	}
+}
+
+func TestRateLimitedTransport(t *testing.T) {
+	var calls int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "1199")
+		w.Write([]byte("[]"))
+	}))
+	defer s.Close()
+
+	now := time.Date(2021, 5, 1, 0, 0, 30, 0, time.UTC)
+	l := NewRateLimiter(0, 0)
+	l.now = func() time.Time { return now }
+	client := &http.Client{Transport: l.Transport(nil)}
+	resp, err := client.Get(s.URL + "/api/v3/ticker/price")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"/api/v3/ticker/price", nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the poll held back, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected 1 call sent, got %d", calls)
+	}
+}
+
+func TestRequestWeight(t *testing.T) {
+	for _, c := range []struct {
+		method, url string
+		weight      int
+		order       bool
+	}{
+		{http.MethodGet, "/api/v3/order?symbol=DOGEUSDT&orderId=1", 4, false},
+		{http.MethodPost, "/api/v3/order", 1, true},
+		{http.MethodDelete, "/api/v3/order", 1, false},
+		{http.MethodPost, "/api/v3/order/oco", 1, true},
+		{http.MethodGet, "/api/v3/ticker/price", 4, false},
+		{http.MethodGet, "/api/v3/ticker/price?symbol=DOGEUSDT", 2, false},
+		{http.MethodGet, "/api/v3/ticker/bookTicker", 4, false},
+		{http.MethodGet, "/api/v3/exchangeInfo", 20, false},
+		{http.MethodGet, "/api/v3/account", 20, false},
+		{http.MethodGet, "/api/v3/myTrades?symbol=DOGEUSDT", 20, false},
+	} {
+		req := httptest.NewRequest(c.method, c.url, nil)
+		if weight, order := requestWeight(req); weight != c.weight || order != c.order {
+			t.Errorf("%s %s: expected %d %v, got %d %v", c.method, c.url, c.weight, c.order, weight, order)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SymbolFilterTypeNotional the NOTIONAL filter replacing MIN_NOTIONAL, unknown to go-binance v2.2.1
+const SymbolFilterTypeNotional binance.SymbolFilterType = "NOTIONAL"
+
+// ErrFilterFailure is returned when an order would break the filters of its symbol.
+var ErrFilterFailure = errors.New("filter failure")
+
+// SymbolRules defines the trading rules of a symbol, parsed from the filters of its exchange info by filterType.
+// Zero values mean the filter is not set.
+type SymbolRules struct {
+	Symbol     string
+	BaseAsset  string
+	QuoteAsset string
+
+	// LOT_SIZE
+	MinQty   float64
+	MaxQty   float64
+	StepSize float64
+
+	// MARKET_LOT_SIZE, the LOT_SIZE still applies to market orders.
+	MarketMinQty   float64
+	MarketMaxQty   float64
+	MarketStepSize float64
+
+	// PRICE_FILTER
+	MinPrice float64
+	MaxPrice float64
+	TickSize float64
+
+	// MIN_NOTIONAL or NOTIONAL
+	MinNotional      float64
+	MaxNotional      float64
+	ApplyMinToMarket bool
+	ApplyMaxToMarket bool
+
+	// PERCENT_PRICE, the price must be in [avgPrice*MultiplierDown, avgPrice*MultiplierUp]
+	MultiplierUp   float64
+	MultiplierDown float64
+
+	// LotSize and PriceSize the decimals of StepSize and TickSize
+	LotSize   int
+	PriceSize int
+}
+
+// NewSymbolRules parses the filters of the symbol.
+func NewSymbolRules(s binance.Symbol) (*SymbolRules, error) {
+	r := &SymbolRules{
+		Symbol:     s.Symbol,
+		BaseAsset:  s.BaseAsset,
+		QuoteAsset: s.QuoteAsset,
+	}
+	var lotSize, priceSize string
+	for _, f := range s.Filters {
+		filterType, _ := f["filterType"].(string)
+		switch binance.SymbolFilterType(filterType) {
+		case binance.SymbolFilterTypeLotSize:
+			r.MinQty = filterFloat(f, "minQty")
+			r.MaxQty = filterFloat(f, "maxQty")
+			r.StepSize = filterFloat(f, "stepSize")
+			lotSize, _ = f["stepSize"].(string)
+		case binance.SymbolFilterTypeMarketLotSize:
+			r.MarketMinQty = filterFloat(f, "minQty")
+			r.MarketMaxQty = filterFloat(f, "maxQty")
+			r.MarketStepSize = filterFloat(f, "stepSize")
+		case binance.SymbolFilterTypePriceFilter:
+			r.MinPrice = filterFloat(f, "minPrice")
+			r.MaxPrice = filterFloat(f, "maxPrice")
+			r.TickSize = filterFloat(f, "tickSize")
+			priceSize, _ = f["tickSize"].(string)
+		case binance.SymbolFilterTypeMinNotional:
+			r.MinNotional = filterFloat(f, "minNotional")
+			r.ApplyMinToMarket, _ = f["applyToMarket"].(bool)
+		case SymbolFilterTypeNotional:
+			r.MinNotional = filterFloat(f, "minNotional")
+			r.MaxNotional = filterFloat(f, "maxNotional")
+			r.ApplyMinToMarket, _ = f["applyMinToMarket"].(bool)
+			r.ApplyMaxToMarket, _ = f["applyMaxToMarket"].(bool)
+		case binance.SymbolFilterTypePercentPrice:
+			r.MultiplierUp = filterFloat(f, "multiplierUp")
+			r.MultiplierDown = filterFloat(f, "multiplierDown")
+		}
+	}
+	if r.StepSize <= 0 {
+		return nil, fmt.Errorf("not found %s in symbol: %s", binance.SymbolFilterTypeLotSize, s.Symbol)
+	}
+	r.LotSize = decimals(lotSize)
+	r.PriceSize = decimals(priceSize)
+	return r, nil
+}
+
+// filterFloat reads a number of the filter, binance sends most of them as strings.
+func filterFloat(f map[string]interface{}, key string) float64 {
+	switch v := f[key].(type) {
+	case string:
+		n, _ := strconv.ParseFloat(v, 64)
+		return n
+	case float64:
+		return v
+	}
+	return 0
+}
+
+// decimals returns the decimals of a step like "0.00100000", which is 3.
+func decimals(step string) int {
+	i := strings.Index(step, ".")
+	if i < 0 {
+		return 0
+	}
+	return len(strings.TrimRight(step[i+1:], "0"))
+}
+
+// roundDown rounds value down to a multiple of step, float errors like 0.29999999 are not rounded down.
+func roundDown(value, step float64, size int) float64 {
+	if step <= 0 {
+		return value
+	}
+	n := math.Floor(value/step + 1e-9)
+	return FloatTrunc(n*step, size)
+}
+
+// RoundQuantity rounds the quantity down to the step size of the order type.
+func (r *SymbolRules) RoundQuantity(quantity float64, market bool) float64 {
+	quantity = roundDown(quantity, r.StepSize, r.LotSize)
+	if market && r.MarketStepSize > 0 {
+		quantity = roundDown(quantity, r.MarketStepSize, r.LotSize)
+	}
+	return quantity
+}
+
+// RoundPrice rounds the price down to the tick size.
+func (r *SymbolRules) RoundPrice(price float64) float64 {
+	return roundDown(price, r.TickSize, r.PriceSize)
+}
+
+// Validate checks an order of quantity at price against the filters before it is sent,
+// price is the last price for market orders. The error wraps ErrFilterFailure.
+func (r *SymbolRules) Validate(quantity, price float64, market bool) error {
+	fail := func(filter binance.SymbolFilterType, format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s %s: %s", ErrFilterFailure, r.Symbol, filter, fmt.Sprintf(format, args...))
+	}
+
+	if quantity <= 0 {
+		return fail(binance.SymbolFilterTypeLotSize, "quantity %v", quantity)
+	}
+	if quantity < r.MinQty {
+		return fail(binance.SymbolFilterTypeLotSize, "quantity %v < min %v", quantity, r.MinQty)
+	}
+	if r.MaxQty > 0 && quantity > r.MaxQty {
+		return fail(binance.SymbolFilterTypeLotSize, "quantity %v > max %v", quantity, r.MaxQty)
+	}
+	if math.Abs(r.RoundQuantity(quantity, false)-quantity) > r.StepSize*1e-6 {
+		return fail(binance.SymbolFilterTypeLotSize, "quantity %v is not a multiple of %v", quantity, r.StepSize)
+	}
+	if market {
+		if quantity < r.MarketMinQty {
+			return fail(binance.SymbolFilterTypeMarketLotSize, "quantity %v < min %v", quantity, r.MarketMinQty)
+		}
+		if r.MarketMaxQty > 0 && quantity > r.MarketMaxQty {
+			return fail(binance.SymbolFilterTypeMarketLotSize, "quantity %v > max %v", quantity, r.MarketMaxQty)
+		}
+	} else {
+		if price < r.MinPrice {
+			return fail(binance.SymbolFilterTypePriceFilter, "price %v < min %v", price, r.MinPrice)
+		}
+		if r.MaxPrice > 0 && price > r.MaxPrice {
+			return fail(binance.SymbolFilterTypePriceFilter, "price %v > max %v", price, r.MaxPrice)
+		}
+		if math.Abs(r.RoundPrice(price)-price) > r.TickSize*1e-6 {
+			return fail(binance.SymbolFilterTypePriceFilter, "price %v is not a multiple of %v", price, r.TickSize)
+		}
+	}
+
+	notional := quantity * price
+	if (!market || r.ApplyMinToMarket) && notional < r.MinNotional {
+		return fail(binance.SymbolFilterTypeMinNotional, "notional %v < min %v", notional, r.MinNotional)
+	}
+	if r.MaxNotional > 0 && (!market || r.ApplyMaxToMarket) && notional > r.MaxNotional {
+		return fail(SymbolFilterTypeNotional, "notional %v > max %v", notional, r.MaxNotional)
+	}
+	return nil
+}
+
+// IsDust tells whether an order of quantity at price is below the min quantity or the min notional,
+// so it can never fill whatever the exchange thinks of the other filters.
+func (r *SymbolRules) IsDust(quantity, price float64, market bool) bool {
+	if quantity <= 0 || quantity < r.MinQty || (market && quantity < r.MarketMinQty) {
+		return true
+	}
+	return (!market || r.ApplyMinToMarket) && quantity*price < r.MinNotional
+}
+
+// ValidatePercentPrice checks a limit price against the PERCENT_PRICE filter around the average price.
+func (r *SymbolRules) ValidatePercentPrice(price, avgPrice float64) error {
+	if avgPrice <= 0 || r.MultiplierUp <= 0 {
+		return nil
+	}
+	if price > avgPrice*r.MultiplierUp || price < avgPrice*r.MultiplierDown {
+		return fmt.Errorf("%w: %s %s: price %v out of [%v, %v]", ErrFilterFailure, r.Symbol, binance.SymbolFilterTypePercentPrice,
+			price, avgPrice*r.MultiplierDown, avgPrice*r.MultiplierUp)
+	}
+	return nil
+}
+
+// SymbolRules returns the trading rules of the symbol from the cached exchange info.
+func (t *Trade) SymbolRules(ctx context.Context, symbol string) (*SymbolRules, error) {
+	if err := t.loadExchangeInfo(ctx); err != nil {
+		return nil, err
+	}
+	c := t.exchangeInfo
+	c.mu.RLock()
+	rules, ok := c.rules[symbol]
+	s, listed := c.symbols[symbol]
+	c.mu.RUnlock()
+	if ok {
+		return rules, nil
+	}
+	if !listed {
+		return nil, fmt.Errorf("symbol not found: %s", symbol)
+	}
+	return NewSymbolRules(s)
+}
@@ -0,0 +1,115 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"github.com/adshao/go-binance/v2"
+	"testing"
+	"time"
+)
+
+func TestSymbolRules(t *testing.T) {
+	// the filters are not in the order of the sim, and MIN_NOTIONAL is replaced by NOTIONAL.
+	rules, err := NewSymbolRules(binance.Symbol{
+		Symbol:     "ETHUSDT",
+		BaseAsset:  "ETH",
+		QuoteAsset: "USDT",
+		Filters: []map[string]interface{}{
+			{"filterType": "NOTIONAL", "minNotional": "5.00000000", "applyMinToMarket": true, "maxNotional": "9000000.00000000", "applyMaxToMarket": false, "avgPriceMins": float64(5)},
+			{"filterType": "MARKET_LOT_SIZE", "minQty": "0.00000000", "maxQty": "100.00000000", "stepSize": "0.00000000"},
+			{"filterType": "LOT_SIZE", "minQty": "0.00010000", "maxQty": "9000.00000000", "stepSize": "0.00010000"},
+			{"filterType": "PRICE_FILTER", "minPrice": "0.01000000", "maxPrice": "1000000.00000000", "tickSize": "0.01000000"},
+			{"filterType": "PERCENT_PRICE", "multiplierUp": "5", "multiplierDown": "0.2", "avgPriceMins": float64(5)},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules.LotSize != 4 || rules.PriceSize != 2 || rules.MinNotional != 5 || rules.MarketMaxQty != 100 {
+		t.Fatalf("unexpected rules %+v", rules)
+	}
+
+	if q := rules.RoundQuantity(0.30009, true); q != 0.3 {
+		t.Fatalf("expected quantity rounded down to 0.3, got %v", q)
+	}
+	if p := rules.RoundPrice(2000.019); p != 2000.01 {
+		t.Fatalf("expected price rounded down to 2000.01, got %v", p)
+	}
+
+	if err := rules.Validate(0.003, 2000, true); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []struct {
+		quantity, price float64
+		market          bool
+	}{
+		{0.002, 2000, true},    // notional 4 < 5
+		{0.00305, 2000, false}, // not a multiple of the step
+		{101, 2000, true},      // MARKET_LOT_SIZE max
+		{0.003, 2000.001, false},
+	}
+	for _, c := range invalid {
+		if err := rules.Validate(c.quantity, c.price, c.market); !errors.Is(err, ErrFilterFailure) {
+			t.Fatalf("expected %+v to fail, got %v", c, err)
+		}
+	}
+	if err := rules.ValidatePercentPrice(11000, 2000); !errors.Is(err, ErrFilterFailure) {
+		t.Fatalf("expected PERCENT_PRICE to fail, got %v", err)
+	}
+}
+
+// noInfoExchange fails to download the exchange info
+type noInfoExchange struct {
+	Exchange
+}
+
+func (e *noInfoExchange) ExchangeInfo(ctx context.Context) (*binance.ExchangeInfo, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestSellWithoutRules(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02)
+	r := newSimRunner(t, sim, testSellOption)
+	ctx := context.Background()
+	r.run(ctx)
+
+	// the exchange info reload failed, a stop loss must still be sent.
+	r.trade.SetExchange(&noInfoExchange{Exchange: sim})
+	if err := r.trade.SellNow(ctx, "DOGEUSDT"); err != nil {
+		t.Fatal(err)
+	}
+	r.trade.sell(ctx, <-r.trade.sellChan)
+	if bill := r.sold(t); bill.Reason != SellReasonManual {
+		t.Fatalf("expected manual sell, got %s", bill.Reason)
+	}
+}
+
+func TestSellDustReportedOnce(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1.00)
+	sim.Deposit("DOGE", 5)
+	r := newSimRunner(t, sim, testSellOption)
+	errs := make(chan Event, 10)
+	r.trade.AddHook(HookFunc(func(e Event) {
+		if e.Type == EventError {
+			errs <- e
+		}
+	}))
+	info := &BoughtInfo{Symbol: "DOGEUSDT", OrderId: 1, Volume: 5, ExecutedQuantity: 5, CummulativeQuoteQuantity: 5, LotSize: 2}
+	r.trade.boughtInfo["DOGEUSDT"] = info
+
+	// 5 DOGE is under the min notional of 10, it never fills.
+	for i := 0; i < 3; i++ {
+		r.trade.sell(context.Background(), &SellBill{Info: info, Reason: SellReasonForStopLoss})
+	}
+	if len(sim.Fills()) != 0 || len(r.trade.getBoughtInfo()) != 1 {
+		t.Fatal("expected no sell order of dust")
+	}
+	<-errs
+	select {
+	case e := <-errs:
+		t.Fatalf("expected the dust reported once, got %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -2,6 +2,7 @@ package trade
 
 import (
 	"context"
+	"github.com/adshao/go-binance/v2"
 	"time"
 )
 
@@ -12,6 +13,9 @@ const (
 	SellReasonForTakeProfit    SellReason = 1
 	SellReasonForStopLoss      SellReason = 2
 	SellReasonForForceStopLoss SellReason = 3
+	SellReasonManual           SellReason = 4
+	SellReasonStrategy         SellReason = 5
+	SellReasonSignal           SellReason = 6
 )
 
 func (s SellReason) String() string {
@@ -22,14 +26,24 @@ func (s SellReason) String() string {
 		return "order already reach to stop loss price/订单已达到止损点"
 	case SellReasonForForceStopLoss:
 		return "order already reach to force stop loss price/订单已达到强制止损点"
+	case SellReasonManual:
+		return "order sold manually/手动卖出"
+	case SellReasonStrategy:
+		return "order sold by strategy exit rule/策略卖出"
+	case SellReasonSignal:
+		return "order sold by external signal/外部信号卖出"
 	}
 	return "unknown"
 }
 
 type SellBill struct {
-	Info        *BoughtInfo
-	Reason      SellReason
-	PriceChange float64
+	Info        *BoughtInfo `json:"info"`
+	Reason      SellReason  `json:"reason"`
+	PriceChange float64     `json:"priceChange"`
+	Time        time.Time   `json:"time"`
+
+	// PnL the realised PnL from the fills, set after the position is sold.
+	PnL *TradePnL `json:"pnl,omitempty"`
 }
 
 func (t *Trade) isBlock(symbol string) bool {
@@ -41,7 +55,7 @@ func (t *Trade) isBlock(symbol string) bool {
 		return false
 	}
 	ti := val.(time.Time)
-	if ti.After(time.Now()) {
+	if ti.After(t.now()) {
 		return true
 	}
 	t.boughtCache.Remove(symbol)
@@ -54,7 +68,7 @@ func (t *Trade) addBlock(symbol string) {
 		return
 	}
 
-	expire := time.Now().Add(option.BuyOption.SameCoinBlockDuration)
+	expire := t.now().Add(option.BuyOption.SameCoinBlockDuration)
 
 	t.cacheMutex.Lock()
 	t.boughtCache.Add(symbol, expire)
@@ -67,21 +81,129 @@ func (t *Trade) runSell(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case sellBill := <-t.sellChan:
-			number := FloatTrunc(sellBill.Info.Volume*0.999, sellBill.Info.LotSize)
-			_, err := t.Sell(ctx, sellBill.Info.Symbol, number)
-			if err != nil {
-				t.logger.WithError(err).Errorf("failed to symbol=%s win=%f %s", sellBill.Info.Symbol, sellBill.PriceChange, sellBill.Reason.String())
-				continue
-			}
-			if t.AfterSell != nil {
-				go t.AfterSell(sellBill)
-			}
+			start := time.Now()
+			t.sell(ctx, sellBill)
+			t.observeLoop("sell", start)
+		}
+	}
+}
+
+// sell sells the position of the bill, bills of positions already sold are dropped.
+func (t *Trade) sell(ctx context.Context, sellBill *SellBill) {
+	ctx = WithPriority(ctx, PrioritySell)
+
+	t.boughtMutex.Lock()
+	info, ok := t.boughtInfo[sellBill.Info.Symbol]
+	t.boughtMutex.Unlock()
+	if !ok || info.OrderId != sellBill.Info.OrderId {
+		return
+	}
 
-			t.boughtMutex.Lock()
-			delete(t.boughtInfo, sellBill.Info.Symbol)
-			t.boughtMutex.Unlock()
+	// the exchange is authoritative, the order is only held back if it is surely too small to fill.
+	number := FloatTrunc(sellBill.Info.Volume*0.999, sellBill.Info.LotSize)
+	if rules, err := t.SymbolRules(ctx, info.Symbol); err != nil {
+		t.logger.WithError(err).Warnf("failed to load the rules of %s, sell it unchecked", info.Symbol)
+	} else {
+		number = rules.RoundQuantity(sellBill.Info.Volume*0.999, true)
+		price := t.lastPrice(info)
+		if err := rules.Validate(number, price, true); err != nil {
+			if rules.IsDust(number, price, true) {
+				t.reportDust(info, err)
+				return
+			}
+			t.logger.WithError(err).Warnf("sell order of %s may be rejected, send it anyway", info.Symbol)
+		}
+	}
 
-			t.addBlock(sellBill.Info.Symbol)
+	// the coins are locked by the OCO.
+	if info.OrderListID != 0 {
+		closed, err := t.cancelOCO(ctx, info)
+		if err != nil {
+			t.logger.WithError(err).Errorf("failed to cancel OCO of %s", info.Symbol)
+			t.emitError(info.Symbol, err)
+			return
+		}
+		if closed {
+			return
 		}
 	}
+
+	resp, err := t.Sell(ctx, sellBill.Info.Symbol, number)
+	if err != nil {
+		t.logger.WithError(err).Errorf("failed to symbol=%s win=%f %s", sellBill.Info.Symbol, sellBill.PriceChange, sellBill.Reason.String())
+		t.emitError(sellBill.Info.Symbol, err)
+		return
+	}
+	t.closePosition(ctx, info, sellBill, resp)
+}
+
+// reportDust reports a position too small to sell once, the sell is tried again on every check
+// as the price may rise over the min notional.
+func (t *Trade) reportDust(info *BoughtInfo, err error) {
+	t.dustMutex.Lock()
+	reported := t.dust[info.Symbol] == info.OrderId
+	t.dust[info.Symbol] = info.OrderId
+	t.dustMutex.Unlock()
+	if reported {
+		t.logger.WithError(err).Debugf("%s is still too small to sell", info.Symbol)
+		return
+	}
+	t.logger.WithError(err).Errorf("%s is too small to sell", info.Symbol)
+	t.emitError(info.Symbol, err)
+}
+
+// lastPrice returns the latest known price of the position, or its buy price if none.
+func (t *Trade) lastPrice(info *BoughtInfo) float64 {
+	if pr, ok := t.book.snapshot(info.Symbol)[info.Symbol]; ok && pr.Price > 0 {
+		return pr.Price
+	}
+	return info.GetPrice()
+}
+
+// closePosition accounts the sell order of the position, it returns false if the position is closed already.
+func (t *Trade) closePosition(ctx context.Context, info *BoughtInfo, sellBill *SellBill, resp *binance.CreateOrderResponse) bool {
+	t.boughtMutex.Lock()
+	current, ok := t.boughtInfo[info.Symbol]
+	if !ok || current.OrderId != info.OrderId {
+		t.boughtMutex.Unlock()
+		return false
+	}
+	delete(t.boughtInfo, info.Symbol)
+	t.boughtMutex.Unlock()
+
+	sellBill.Time = t.now()
+	base, quote := info.assets(t.Option().BuyOption)
+	fills := t.summarizeFills(ctx, resp, base, quote)
+	sellBill.PnL = tradePnL(info, resp.OrderID, sellBill.Time, fills)
+	sellBill.PnL.QuoteAsset = quote
+	sellBill.PnL.ReportAsset = t.Option().BuyOption.MainCoin
+	sellBill.PnL.ReportRate = t.reportRate(ctx, quote)
+	if sellBill.PnL.BuyPrice != 0 {
+		sellBill.PriceChange = (sellBill.PnL.SellPrice - sellBill.PnL.BuyPrice) / sellBill.PnL.BuyPrice * 100
+	}
+	t.pnl.add(sellBill.PnL, quote)
+	t.logger.Infof("sold %s %s pnl=%f %s (%.2f%%) commission=%f",
+		sellBill.Info.Symbol, sellBill.Reason.String(), sellBill.PnL.PnL, quote, sellBill.PnL.PnLPercent, sellBill.PnL.Commission)
+
+	t.addSellHistory(sellBill)
+	sellsTotal.WithLabelValues(t.metricsLabel(), sellBill.Reason.label()).Inc()
+	t.updatePositionMetrics()
+	t.record(&JournalEntry{
+		Type:        JournalSell,
+		Symbol:      sellBill.Info.Symbol,
+		Time:        sellBill.Time,
+		BuyOrderID:  sellBill.Info.OrderId,
+		Reason:      sellBill.Reason,
+		PriceChange: sellBill.PriceChange,
+		Commission:  fills.Commission,
+		PnL:         sellBill.PnL,
+	}, resp)
+	if t.AfterSell != nil {
+		go t.AfterSell(sellBill)
+	}
+	t.emit(Event{Type: EventSell, Symbol: sellBill.Info.Symbol, Sell: sellBill})
+
+	t.addBlock(sellBill.Info.Symbol)
+	t.save()
+	return true
 }
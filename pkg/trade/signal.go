@@ -0,0 +1,126 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SignalAction defines what an external signal asks for
+type SignalAction string
+
+const (
+	SignalBuy SignalAction = "buy"
+
+	// SignalSell and SignalClose both sell the whole position of the symbol.
+	SignalSell  SignalAction = "sell"
+	SignalClose SignalAction = "close"
+)
+
+// ExternalSignal defines a signal generated outside the bot, eg: a TradingView alert.
+type ExternalSignal struct {
+	Action SignalAction `json:"action"`
+	Symbol string       `json:"symbol"`
+
+	// Size the quote asset to spend on a buy, default: MoneyPerOrder of the quote
+	Size float64 `json:"size,omitempty"`
+
+	// TakeProfit and StopLoss override the SellOption of the position, in percent.
+	TakeProfit *float64 `json:"takeProfit,omitempty"`
+	StopLoss   *float64 `json:"stopLoss,omitempty"`
+}
+
+// ErrSignalRejected the signal does not pass the validation
+var ErrSignalRejected = errors.New("signal rejected")
+
+func rejectSignal(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrSignalRejected, fmt.Sprintf(format, args...))
+}
+
+// validate checks the signal against the risk limits,
+// MaxBuy and the positions we hold are checked when buying.
+func (s *ExternalSignal) validate(option BuyOption) error {
+	s.Symbol = strings.ToUpper(strings.TrimSpace(s.Symbol))
+	if s.Symbol == "" {
+		return rejectSignal("symbol is required")
+	}
+	switch s.Action {
+	case SignalSell, SignalClose:
+		return nil
+	case SignalBuy:
+	default:
+		return rejectSignal("unknown action %q", s.Action)
+	}
+
+	quote, ok := option.quoteOf(s.Symbol)
+	if !ok {
+		return rejectSignal("%s is not quoted in any of the quotes", s.Symbol)
+	}
+	maxSize := quote.SignalMaxSize
+	if maxSize == 0 {
+		maxSize = quote.MoneyPerOrder
+	}
+	if s.Size < 0 || s.Size > maxSize {
+		return rejectSignal("size %v out of range (0, %v]", s.Size, maxSize)
+	}
+	if s.TakeProfit != nil && *s.TakeProfit <= 0 {
+		return rejectSignal("take profit must be positive")
+	}
+	if s.StopLoss != nil && (*s.StopLoss <= 0 || *s.StopLoss >= 100) {
+		return rejectSignal("stop loss must be in (0, 100)")
+	}
+	return nil
+}
+
+// HandleSignal executes the signal, buys are placed at once and sells are enqueued.
+// The position is returned for buys.
+func (t *Trade) HandleSignal(ctx context.Context, s ExternalSignal) (*BoughtInfo, error) {
+	option := t.Option()
+	if err := s.validate(option.BuyOption); err != nil {
+		return nil, err
+	}
+	t.logger.Infof("received %s signal of %s", s.Action, s.Symbol)
+
+	if s.Action != SignalBuy {
+		t.boughtMutex.Lock()
+		info, ok := t.boughtInfo[s.Symbol]
+		var i BoughtInfo
+		if ok {
+			i = *info
+		}
+		t.boughtMutex.Unlock()
+		if !ok {
+			return nil, rejectSignal("position not found: %s", s.Symbol)
+		}
+		return nil, t.enqueueSell(ctx, &i, SellReasonSignal)
+	}
+
+	if !t.inUniverse(s.Symbol) {
+		return nil, rejectSignal("%s is not in the symbol universe", s.Symbol)
+	}
+	if t.Paused() {
+		return nil, rejectSignal("buying is paused")
+	}
+	if t.isBlock(s.Symbol) {
+		return nil, rejectSignal("%s is blocked after a recent sell", s.Symbol)
+	}
+	pr, ok := t.GetSymbolPrice(ctx, s.Symbol)[s.Symbol]
+	if !ok {
+		return nil, fmt.Errorf("price not found: %s", s.Symbol)
+	}
+	info, err := t.buyPosition(ctx, s.Symbol, pr.Price, &positionOverride{
+		Money:      s.Size,
+		TakeProfit: s.TakeProfit,
+		StopLoss:   s.StopLoss,
+	})
+	if err == errMaxBuy || err == errBought || errors.Is(err, ErrFilterFailure) {
+		return nil, rejectSignal("%s", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	buysTotal.WithLabelValues(t.metricsLabel(), buyReasonSignal).Inc()
+	t.save()
+	return info, nil
+}
@@ -0,0 +1,48 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHandleSignal(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1)
+	sim.SetPrice("ETHUSDT", 1)
+	sim.SetPrice("XRPUSDT", 1)
+	r := newSimRunner(t, sim, testSellOption)
+	ctx := context.Background()
+
+	tp, sl := 5.0, 1.0
+	info, err := r.trade.HandleSignal(ctx, ExternalSignal{Action: SignalBuy, Symbol: "dogeusdt", Size: 11, TakeProfit: &tp, StopLoss: &sl})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CummulativeQuoteQuantity != 11 || info.TakeProfit != 5 || *info.StopLoss != -1 {
+		t.Fatalf("unexpected position %+v", info)
+	}
+
+	rejected := []ExternalSignal{
+		{Action: SignalBuy, Symbol: "XRPUSDT"},
+		{Action: SignalBuy, Symbol: "ETHUSDT", Size: 12},
+		{Action: SignalBuy, Symbol: "ETHUSDT", Size: 5},
+		{Action: SignalBuy, Symbol: "ETHUSDT", StopLoss: &tp, TakeProfit: new(float64)},
+		{Action: SignalBuy, Symbol: "DOGEUSDT"},
+		{Action: SignalClose, Symbol: "ETHUSDT"},
+		{Action: "hold", Symbol: "ETHUSDT"},
+	}
+	for _, s := range rejected {
+		if _, err := r.trade.HandleSignal(ctx, s); !errors.Is(err, ErrSignalRejected) {
+			t.Fatalf("expected %+v rejected, got %v", s, err)
+		}
+	}
+
+	if _, err := r.trade.HandleSignal(ctx, ExternalSignal{Action: SignalClose, Symbol: "DOGEUSDT"}); err != nil {
+		t.Fatal(err)
+	}
+	r.trade.sell(ctx, <-r.trade.sellChan)
+	if bill := r.sold(t); bill.Reason != SellReasonSignal {
+		t.Fatalf("expected sold by signal, got %s", bill.Reason)
+	}
+}
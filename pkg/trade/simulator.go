@@ -0,0 +1,739 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"github.com/adshao/go-binance/v2/common"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SimSymbol defines a symbol listed on the SimExchange
+type SimSymbol struct {
+	Symbol      string
+	BaseAsset   string
+	QuoteAsset  string
+	StepSize    string
+	TickSize    string
+	MinNotional string
+}
+
+// SimExchange is an in-memory Exchange, market orders are filled immediately at the current price,
+// limit orders and OCO legs rest until the price crosses them. It is used by tests and backtests,
+// prices are scripted with SetPricePath and moved with Step.
+type SimExchange struct {
+	mu sync.Mutex
+
+	// Fee the commission rate of every fill, eg: 0.001 means 0.1%
+	Fee float64
+
+	// Slippage the rate market orders are filled worse than the current price, eg: 0.0005
+	Slippage float64
+
+	// PartialFill the part of a resting limit order filled each time the price crosses it,
+	// eg: 0.5 fills half of the quantity. 0 fills it all at once.
+	PartialFill float64
+
+	symbols  map[string]SimSymbol
+	prices   map[string]float64
+	paths    map[string][]float64
+	balances map[string]float64
+	orders   map[int64]*binance.Order
+	orderID  int64
+	fills    []SimFill
+	now      time.Time
+
+	open   map[int64]*simOpenOrder
+	lists  map[int64]*simOrderList
+	listID int64
+}
+
+// simOpenOrder an order resting on the book
+type simOpenOrder struct {
+	order     *binance.Order
+	price     float64
+	stopPrice float64
+	listID    int64
+
+	// locked the balance reserved by an order out of lists, quote for buys and base for sells.
+	locked float64
+}
+
+// simOrderList the legs of an OCO share the locked balance
+type simOrderList struct {
+	orders []int64
+	asset  string
+	locked float64
+}
+
+// SimFill records an order filled by the SimExchange
+type SimFill struct {
+	OrderID  int64
+	Symbol   string
+	Side     binance.SideType
+	Quantity float64
+	Price    float64
+	Quote    float64
+	Fee      float64
+	Time     time.Time
+}
+
+// NewSimExchange returns a SimExchange with given balances, eg: {"USDT": 1000}
+func NewSimExchange(balances map[string]float64) *SimExchange {
+	s := &SimExchange{
+		symbols:  make(map[string]SimSymbol),
+		prices:   make(map[string]float64),
+		paths:    make(map[string][]float64),
+		balances: make(map[string]float64),
+		orders:   make(map[int64]*binance.Order),
+		open:     make(map[int64]*simOpenOrder),
+		lists:    make(map[int64]*simOrderList),
+	}
+	for asset, free := range balances {
+		s.balances[asset] = free
+	}
+	return s
+}
+
+// AddSymbol lists a symbol on the exchange, empty filters take binance's common values.
+func (s *SimExchange) AddSymbol(symbol SimSymbol) {
+	if symbol.StepSize == "" {
+		symbol.StepSize = "0.01000000"
+	}
+	if symbol.TickSize == "" {
+		symbol.TickSize = "0.00000100"
+	}
+	if symbol.MinNotional == "" {
+		symbol.MinNotional = "10.00000000"
+	}
+
+	s.mu.Lock()
+	s.symbols[symbol.Symbol] = symbol
+	s.mu.Unlock()
+}
+
+// SetPrice set the current price of symbol
+func (s *SimExchange) SetPrice(symbol string, price float64) {
+	s.mu.Lock()
+	s.prices[symbol] = price
+	s.matchLocked(symbol)
+	s.mu.Unlock()
+}
+
+// SetPricePath scripts the prices of symbol, the first price is effective immediately,
+// each Step moves to the next one.
+func (s *SimExchange) SetPricePath(symbol string, prices ...float64) {
+	if len(prices) == 0 {
+		return
+	}
+	s.mu.Lock()
+	s.prices[symbol] = prices[0]
+	s.paths[symbol] = prices[1:]
+	s.matchLocked(symbol)
+	s.mu.Unlock()
+}
+
+// Step moves every scripted symbol to its next price, returns false once all paths are exhausted.
+func (s *SimExchange) Step() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var moved bool
+	for symbol, path := range s.paths {
+		if len(path) == 0 {
+			continue
+		}
+		s.prices[symbol] = path[0]
+		s.paths[symbol] = path[1:]
+		s.matchLocked(symbol)
+		moved = true
+	}
+	return moved
+}
+
+// SetTime set the virtual time of the exchange, zero means the wall clock.
+func (s *SimExchange) SetTime(now time.Time) {
+	s.mu.Lock()
+	s.now = now
+	s.mu.Unlock()
+}
+
+// Now returns the time of the exchange
+func (s *SimExchange) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.timeLocked()
+}
+
+func (s *SimExchange) timeLocked() time.Time {
+	if s.now.IsZero() {
+		return time.Now()
+	}
+	return s.now
+}
+
+// Fills returns all fills in order
+func (s *SimExchange) Fills() []SimFill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SimFill(nil), s.fills...)
+}
+
+// Deposit adds amount to the balance of asset
+func (s *SimExchange) Deposit(asset string, amount float64) {
+	s.mu.Lock()
+	s.balances[asset] += amount
+	s.mu.Unlock()
+}
+
+// Balance returns the free balance of asset
+func (s *SimExchange) Balance(asset string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.balances[asset]
+}
+
+func (s *SimExchange) ListPrices(ctx context.Context, symbol string) ([]*binance.SymbolPrice, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []*binance.SymbolPrice
+	for sym, price := range s.prices {
+		if symbol != "" && sym != symbol {
+			continue
+		}
+		res = append(res, &binance.SymbolPrice{
+			Symbol: sym,
+			Price:  formatFloat(price),
+		})
+	}
+	return res, nil
+}
+
+func (s *SimExchange) ExchangeInfo(ctx context.Context) (*binance.ExchangeInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := &binance.ExchangeInfo{
+		Timezone: "UTC",
+	}
+	for _, sym := range s.symbols {
+		// keep the same filters order as binance returns.
+		info.Symbols = append(info.Symbols, binance.Symbol{
+			Symbol:               sym.Symbol,
+			Status:               string(binance.SymbolStatusTypeTrading),
+			BaseAsset:            sym.BaseAsset,
+			QuoteAsset:           sym.QuoteAsset,
+			BaseAssetPrecision:   8,
+			QuotePrecision:       8,
+			OrderTypes:           []string{string(binance.OrderTypeLimit), string(binance.OrderTypeMarket)},
+			IsSpotTradingAllowed: true,
+			Filters: []map[string]interface{}{
+				{
+					"filterType": string(binance.SymbolFilterTypePriceFilter),
+					"minPrice":   sym.TickSize,
+					"maxPrice":   "1000000.00000000",
+					"tickSize":   sym.TickSize,
+				},
+				{
+					"filterType":     string(binance.SymbolFilterTypePercentPrice),
+					"multiplierUp":   "5",
+					"multiplierDown": "0.2",
+					"avgPriceMins":   float64(5),
+				},
+				{
+					"filterType": string(binance.SymbolFilterTypeLotSize),
+					"minQty":     sym.StepSize,
+					"maxQty":     "9000000.00000000",
+					"stepSize":   sym.StepSize,
+				},
+				{
+					"filterType":    string(binance.SymbolFilterTypeMinNotional),
+					"minNotional":   sym.MinNotional,
+					"applyToMarket": true,
+					"avgPriceMins":  float64(5),
+				},
+				{
+					"filterType": string(binance.SymbolFilterTypeMarketLotSize),
+					"minQty":     "0.00000000",
+					"maxQty":     "9000000.00000000",
+					"stepSize":   "0.00000000",
+				},
+			},
+		})
+	}
+	return info, nil
+}
+
+func (s *SimExchange) CreateOrder(ctx context.Context, req OrderRequest) (*binance.CreateOrderResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sym, ok := s.symbols[req.Symbol]
+	if !ok {
+		return nil, &common.APIError{Code: -1121, Message: "Invalid symbol."}
+	}
+	price, ok := s.prices[req.Symbol]
+	if !ok || price <= 0 {
+		return nil, &common.APIError{Code: -1013, Message: "Market is closed."}
+	}
+	if req.Quantity <= 0 {
+		return nil, &common.APIError{Code: -1013, Message: "Invalid quantity."}
+	}
+	if req.Side != binance.SideTypeBuy && req.Side != binance.SideTypeSell {
+		return nil, &common.APIError{Code: -1117, Message: "Invalid side."}
+	}
+	switch req.Type {
+	case binance.OrderTypeMarket:
+		if req.Side == binance.SideTypeBuy {
+			price *= 1 + s.Slippage
+		} else {
+			price *= 1 - s.Slippage
+		}
+		return s.takeLocked(sym, req, price)
+	case binance.OrderTypeLimit, binance.OrderTypeLimitMaker:
+		return s.limitLocked(sym, req, price)
+	}
+	return nil, &common.APIError{Code: -1116, Message: "Invalid orderType."}
+}
+
+// limitLocked fills a marketable LIMIT order at once, or rests it on the book.
+func (s *SimExchange) limitLocked(sym SimSymbol, req OrderRequest, price float64) (*binance.CreateOrderResponse, error) {
+	if req.Price <= 0 {
+		return nil, &common.APIError{Code: -1013, Message: "Invalid price."}
+	}
+	marketable := price <= req.Price
+	if req.Side == binance.SideTypeSell {
+		marketable = price >= req.Price
+	}
+	if marketable {
+		if req.Type == binance.OrderTypeLimitMaker {
+			return nil, &common.APIError{Code: -2010, Message: "Order would immediately match and take."}
+		}
+		return s.takeLocked(sym, req, price)
+	}
+
+	open := &simOpenOrder{price: req.Price}
+	if req.Side == binance.SideTypeBuy {
+		open.locked = req.Quantity * req.Price
+		if s.balances[sym.QuoteAsset] < open.locked*(1+s.Fee) {
+			return nil, &common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."}
+		}
+		s.balances[sym.QuoteAsset] -= open.locked
+	} else {
+		open.locked = req.Quantity
+		if s.balances[sym.BaseAsset] < open.locked {
+			return nil, &common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."}
+		}
+		s.balances[sym.BaseAsset] -= open.locked
+	}
+	open.order = s.newOrderLocked(req.Symbol, req.Side, req.Type, req.Quantity)
+	open.order.Price = formatFloat(req.Price)
+	open.order.Status = binance.OrderStatusTypeNew
+	open.order.ExecutedQuantity = formatFloat(0)
+	open.order.CummulativeQuoteQuantity = formatFloat(0)
+	if req.Type == binance.OrderTypeLimit {
+		open.order.TimeInForce = binance.TimeInForceTypeGTC
+	}
+	s.open[open.order.OrderID] = open
+
+	order := open.order
+	return &binance.CreateOrderResponse{
+		Symbol:                   order.Symbol,
+		OrderID:                  order.OrderID,
+		ClientOrderID:            order.ClientOrderID,
+		Price:                    order.Price,
+		OrigQuantity:             order.OrigQuantity,
+		ExecutedQuantity:         order.ExecutedQuantity,
+		CummulativeQuoteQuantity: order.CummulativeQuoteQuantity,
+		Status:                   order.Status,
+		TimeInForce:              order.TimeInForce,
+		Type:                     order.Type,
+		Side:                     order.Side,
+		TransactTime:             order.Time,
+	}, nil
+}
+
+// takeLocked fills the order at once at fillPrice
+func (s *SimExchange) takeLocked(sym SimSymbol, req OrderRequest, fillPrice float64) (*binance.CreateOrderResponse, error) {
+	var (
+		quantity = req.Quantity
+		quote    float64
+		fee      float64
+	)
+	switch req.Side {
+	case binance.SideTypeBuy:
+		quote = quantity * fillPrice
+		fee = quote * s.Fee
+		if s.balances[sym.QuoteAsset] < quote+fee {
+			return nil, &common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."}
+		}
+		s.balances[sym.QuoteAsset] -= quote + fee
+		s.balances[sym.BaseAsset] += quantity
+	case binance.SideTypeSell:
+		quote = quantity * fillPrice
+		fee = quote * s.Fee
+		if s.balances[sym.BaseAsset] < quantity {
+			return nil, &common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."}
+		}
+		s.balances[sym.BaseAsset] -= quantity
+		s.balances[sym.QuoteAsset] += quote - fee
+	}
+
+	now := s.timeLocked()
+	order := s.newOrderLocked(req.Symbol, req.Side, req.Type, quantity)
+	order.ExecutedQuantity = formatFloat(quantity)
+	order.CummulativeQuoteQuantity = formatFloat(quote)
+	order.Status = binance.OrderStatusTypeFilled
+	order.IsWorking = false
+	if req.Type != binance.OrderTypeMarket {
+		order.Price = formatFloat(req.Price)
+	}
+	s.fills = append(s.fills, SimFill{
+		OrderID:  order.OrderID,
+		Symbol:   req.Symbol,
+		Side:     req.Side,
+		Quantity: quantity,
+		Price:    fillPrice,
+		Quote:    quote,
+		Fee:      fee,
+		Time:     now,
+	})
+
+	return &binance.CreateOrderResponse{
+		Symbol:                   order.Symbol,
+		OrderID:                  order.OrderID,
+		ClientOrderID:            order.ClientOrderID,
+		Price:                    order.Price,
+		OrigQuantity:             order.OrigQuantity,
+		ExecutedQuantity:         order.ExecutedQuantity,
+		CummulativeQuoteQuantity: order.CummulativeQuoteQuantity,
+		Status:                   order.Status,
+		Type:                     order.Type,
+		Side:                     order.Side,
+		TransactTime:             order.Time,
+		Fills: []*binance.Fill{
+			{
+				Price:           formatFloat(fillPrice),
+				Quantity:        formatFloat(quantity),
+				Commission:      formatFloat(fee),
+				CommissionAsset: sym.QuoteAsset,
+			},
+		},
+	}, nil
+}
+
+func (s *SimExchange) GetOrder(ctx context.Context, symbol string, id int64) (*binance.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok || order.Symbol != symbol {
+		return nil, &common.APIError{Code: -2013, Message: "Order does not exist."}
+	}
+	o := *order
+	return &o, nil
+}
+
+func (s *SimExchange) Balances(ctx context.Context) ([]binance.Balance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []binance.Balance
+	for asset, free := range s.balances {
+		res = append(res, binance.Balance{
+			Asset:  asset,
+			Free:   formatFloat(free),
+			Locked: "0.00000000",
+		})
+	}
+	return res, nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 8, 64)
+}
+
+// CreateOCO places a SELL OCO, the quantity is locked until a leg fills or the list is cancelled.
+func (s *SimExchange) CreateOCO(ctx context.Context, req OCORequest) (*binance.CreateOCOResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sym, ok := s.symbols[req.Symbol]
+	if !ok {
+		return nil, &common.APIError{Code: -1121, Message: "Invalid symbol."}
+	}
+	if req.Side != binance.SideTypeSell {
+		return nil, &common.APIError{Code: -1117, Message: "Invalid side."}
+	}
+	if req.Quantity <= 0 {
+		return nil, &common.APIError{Code: -1013, Message: "Invalid quantity."}
+	}
+	price := s.prices[req.Symbol]
+	if !(req.Price > price && price > req.StopPrice) {
+		return nil, &common.APIError{Code: -2010, Message: "The relationship of the prices for the orders is not correct."}
+	}
+	if s.balances[sym.BaseAsset] < req.Quantity {
+		return nil, &common.APIError{Code: -2010, Message: "Account has insufficient balance for requested action."}
+	}
+	s.balances[sym.BaseAsset] -= req.Quantity
+
+	s.listID++
+	list := &simOrderList{asset: sym.BaseAsset, locked: req.Quantity}
+	s.lists[s.listID] = list
+	resp := &binance.CreateOCOResponse{
+		OrderListID:     s.listID,
+		ContingencyType: "OCO",
+		ListStatusType:  "EXEC_STARTED",
+		ListOrderStatus: "EXECUTING",
+		Symbol:          req.Symbol,
+		TransactionTime: binance.FormatTimestamp(s.timeLocked()),
+	}
+	legs := []struct {
+		typ       binance.OrderType
+		price     float64
+		stopPrice float64
+	}{
+		{binance.OrderTypeLimitMaker, req.Price, 0},
+		{binance.OrderTypeStopLossLimit, req.StopLimitPrice, req.StopPrice},
+	}
+	for _, leg := range legs {
+		order := s.newOrderLocked(req.Symbol, req.Side, leg.typ, req.Quantity)
+		order.Price = formatFloat(leg.price)
+		order.StopPrice = formatFloat(leg.stopPrice)
+		order.Status = binance.OrderStatusTypeNew
+		order.ExecutedQuantity = formatFloat(0)
+		order.CummulativeQuoteQuantity = formatFloat(0)
+		s.open[order.OrderID] = &simOpenOrder{order: order, price: leg.price, stopPrice: leg.stopPrice, listID: s.listID}
+		list.orders = append(list.orders, order.OrderID)
+		resp.Orders = append(resp.Orders, &binance.OCOOrder{
+			Symbol:        order.Symbol,
+			OrderID:       order.OrderID,
+			ClientOrderID: order.ClientOrderID,
+		})
+		resp.OrderReports = append(resp.OrderReports, &binance.OCOOrderReport{
+			Symbol:                   order.Symbol,
+			OrderID:                  order.OrderID,
+			OrderListID:              s.listID,
+			ClientOrderID:            order.ClientOrderID,
+			TransactionTime:          order.Time,
+			Price:                    order.Price,
+			OrigQuantity:             order.OrigQuantity,
+			ExecutedQuantity:         order.ExecutedQuantity,
+			CummulativeQuoteQuantity: order.CummulativeQuoteQuantity,
+			Status:                   order.Status,
+			TimeInForce:              binance.TimeInForceTypeGTC,
+			Type:                     order.Type,
+			Side:                     order.Side,
+			StopPrice:                order.StopPrice,
+		})
+	}
+	return resp, nil
+}
+
+// CancelOrder cancels an open order, and the other legs of its list.
+func (s *SimExchange) CancelOrder(ctx context.Context, symbol string, id int64) (*binance.CancelOrderResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	open, ok := s.open[id]
+	if !ok || open.order.Symbol != symbol {
+		return nil, &common.APIError{Code: -2011, Message: "Unknown order sent."}
+	}
+	if list, ok := s.lists[open.listID]; ok {
+		for _, oid := range list.orders {
+			s.closeLocked(oid, binance.OrderStatusTypeCanceled)
+		}
+		s.balances[list.asset] += list.locked
+		delete(s.lists, open.listID)
+	} else {
+		sym := s.symbols[symbol]
+		if open.order.Side == binance.SideTypeBuy {
+			s.balances[sym.QuoteAsset] += open.locked
+		} else {
+			s.balances[sym.BaseAsset] += open.locked
+		}
+		s.closeLocked(id, binance.OrderStatusTypeCanceled)
+	}
+	order := s.orders[id]
+	return &binance.CancelOrderResponse{
+		Symbol:                   order.Symbol,
+		OrderID:                  order.OrderID,
+		ClientOrderID:            order.ClientOrderID,
+		Price:                    order.Price,
+		OrigQuantity:             order.OrigQuantity,
+		ExecutedQuantity:         order.ExecutedQuantity,
+		CummulativeQuoteQuantity: order.CummulativeQuoteQuantity,
+		Status:                   order.Status,
+		Type:                     order.Type,
+		Side:                     order.Side,
+		OrderListID:              open.listID,
+	}, nil
+}
+
+// OrderFills returns the fills of the order
+func (s *SimExchange) OrderFills(ctx context.Context, symbol string, id int64) ([]*binance.Fill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[id]
+	if !ok || order.Symbol != symbol {
+		return nil, &common.APIError{Code: -2013, Message: "Order does not exist."}
+	}
+	quote := s.symbols[symbol].QuoteAsset
+	var fills []*binance.Fill
+	for _, f := range s.fills {
+		if f.OrderID != id {
+			continue
+		}
+		fills = append(fills, &binance.Fill{
+			Price:           formatFloat(f.Price),
+			Quantity:        formatFloat(f.Quantity),
+			Commission:      formatFloat(f.Fee),
+			CommissionAsset: quote,
+		})
+	}
+	return fills, nil
+}
+
+func (s *SimExchange) newOrderLocked(symbol string, side binance.SideType, typ binance.OrderType, quantity float64) *binance.Order {
+	s.orderID++
+	now := binance.FormatTimestamp(s.timeLocked())
+	order := &binance.Order{
+		Symbol:        symbol,
+		OrderID:       s.orderID,
+		ClientOrderID: fmt.Sprintf("sim-%d", s.orderID),
+		Price:         "0.00000000",
+		OrigQuantity:  formatFloat(quantity),
+		Type:          typ,
+		Side:          side,
+		Time:          now,
+		UpdateTime:    now,
+		IsWorking:     true,
+	}
+	s.orders[order.OrderID] = order
+	return order
+}
+
+// closeLocked takes the order off the book
+func (s *SimExchange) closeLocked(id int64, status binance.OrderStatusType) {
+	if _, ok := s.open[id]; !ok {
+		return
+	}
+	delete(s.open, id)
+	order := s.orders[id]
+	order.Status = status
+	order.IsWorking = false
+	order.UpdateTime = binance.FormatTimestamp(s.timeLocked())
+}
+
+// matchLocked fills the open orders of symbol crossed by the current price, at their limit price.
+func (s *SimExchange) matchLocked(symbol string) {
+	price := s.prices[symbol]
+	var ids []int64
+	for id, open := range s.open {
+		if open.order.Symbol == symbol {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		open, ok := s.open[id]
+		if !ok {
+			// the other leg filled.
+			continue
+		}
+		var crossed bool
+		switch open.order.Type {
+		case binance.OrderTypeStopLossLimit:
+			crossed = price <= open.stopPrice
+		default:
+			if open.order.Side == binance.SideTypeSell {
+				crossed = price >= open.price
+			} else {
+				crossed = price <= open.price
+			}
+		}
+		if crossed {
+			s.fillLocked(open)
+		}
+	}
+}
+
+func (s *SimExchange) fillLocked(open *simOpenOrder) {
+	var (
+		order       = open.order
+		sym         = s.symbols[order.Symbol]
+		orig, _     = strconv.ParseFloat(order.OrigQuantity, 64)
+		executed, _ = strconv.ParseFloat(order.ExecutedQuantity, 64)
+		cumQuote, _ = strconv.ParseFloat(order.CummulativeQuoteQuantity, 64)
+		quantity    = orig - executed
+	)
+	if s.PartialFill > 0 && open.listID == 0 && orig*s.PartialFill < quantity {
+		quantity = orig * s.PartialFill
+	}
+	var (
+		quote = quantity * open.price
+		fee   = quote * s.Fee
+	)
+	if order.Side == binance.SideTypeSell {
+		s.balances[sym.QuoteAsset] += quote - fee
+		open.locked -= quantity
+	} else {
+		s.balances[sym.BaseAsset] += quantity
+		s.balances[sym.QuoteAsset] -= fee
+		open.locked -= quote
+	}
+	if list, ok := s.lists[open.listID]; ok {
+		for _, oid := range list.orders {
+			if oid != order.OrderID {
+				s.closeLocked(oid, binance.OrderStatusTypeExpired)
+			}
+		}
+		delete(s.lists, open.listID)
+	}
+	order.ExecutedQuantity = formatFloat(executed + quantity)
+	order.CummulativeQuoteQuantity = formatFloat(cumQuote + quote)
+	if executed+quantity >= orig {
+		s.closeLocked(order.OrderID, binance.OrderStatusTypeFilled)
+	} else {
+		order.Status = binance.OrderStatusTypePartiallyFilled
+		order.UpdateTime = binance.FormatTimestamp(s.timeLocked())
+	}
+	s.fills = append(s.fills, SimFill{
+		OrderID:  order.OrderID,
+		Symbol:   order.Symbol,
+		Side:     order.Side,
+		Quantity: quantity,
+		Price:    open.price,
+		Quote:    quote,
+		Fee:      fee,
+		Time:     s.timeLocked(),
+	})
+}
+
+// BookTickers returns the current price as both bid and ask, the sim has no spread.
+func (s *SimExchange) BookTickers(ctx context.Context, symbol string) ([]*binance.BookTicker, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res []*binance.BookTicker
+	for sym, price := range s.prices {
+		if symbol != "" && sym != symbol {
+			continue
+		}
+		res = append(res, &binance.BookTicker{
+			Symbol:   sym,
+			BidPrice: formatFloat(price),
+			AskPrice: formatFloat(price),
+		})
+	}
+	return res, nil
+}
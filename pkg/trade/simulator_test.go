@@ -0,0 +1,226 @@
+package trade
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type simRunner struct {
+	trade *Trade
+	sim   *SimExchange
+	sells chan *SellBill
+}
+
+func newSimRunner(t *testing.T, sim *SimExchange, sellOption SellOption) *simRunner {
+	up := 1.0
+	tr := NewTrade(
+		WithBuyOption(BuyOption{
+			Interval:      time.Second,
+			PriceUpChange: &up,
+			MaxBuy:        2,
+			MoneyPerOrder: 11,
+			MainCoin:      "USDT",
+			WhiteList:     []string{"DOGE", "ETH", "BNB"},
+		}),
+		WithSellOption(sellOption),
+	)
+	tr.SetExchange(sim)
+
+	r := &simRunner{
+		trade: tr,
+		sim:   sim,
+		sells: make(chan *SellBill, 10),
+	}
+	tr.AfterSell = func(info *SellBill) {
+		r.sells <- info
+	}
+	return r
+}
+
+// run drives the buy and TP/SL checks once for every scripted price.
+func (r *simRunner) run(ctx context.Context) {
+	for {
+		option := r.trade.Option()
+		r.trade.buy(ctx, r.trade.checkingPrice(ctx, option))
+		if err := r.trade.checkingTPSL(ctx, option); err != nil {
+			panic(err)
+		}
+		for len(r.trade.sellChan) > 0 {
+			r.trade.sell(ctx, <-r.trade.sellChan)
+		}
+		if !r.sim.Step() {
+			return
+		}
+	}
+}
+
+func (r *simRunner) sold(t *testing.T) *SellBill {
+	select {
+	case bill := <-r.sells:
+		return bill
+	case <-time.After(time.Second):
+		t.Fatal("expected a sell")
+	}
+	return nil
+}
+
+func newTestSim() *SimExchange {
+	sim := NewSimExchange(map[string]float64{"USDT": 100})
+	sim.AddSymbol(SimSymbol{Symbol: "DOGEUSDT", BaseAsset: "DOGE", QuoteAsset: "USDT"})
+	sim.AddSymbol(SimSymbol{Symbol: "ETHUSDT", BaseAsset: "ETH", QuoteAsset: "USDT", StepSize: "0.00010000"})
+	sim.AddSymbol(SimSymbol{Symbol: "BNBUSDT", BaseAsset: "BNB", QuoteAsset: "USDT", StepSize: "0.00100000"})
+	sim.AddSymbol(SimSymbol{Symbol: "XRPUSDT", BaseAsset: "XRP", QuoteAsset: "USDT"})
+	return sim
+}
+
+var testSellOption = SellOption{
+	StopLoss:      1.5,
+	TakeProfit:    2,
+	ForceStopLoss: 3,
+	Interval:      time.Second,
+}
+
+func TestSimTakeProfit(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.03, 1.05)
+	r := newSimRunner(t, sim, testSellOption)
+	r.run(context.Background())
+
+	bill := r.sold(t)
+	if bill.Reason != SellReasonForTakeProfit {
+		t.Fatalf("expected take profit, got %s", bill.Reason)
+	}
+	if bill.Info.ExecutedQuantity != 10.78 {
+		t.Fatalf("expected to buy 10.78 DOGE, got %f", bill.Info.ExecutedQuantity)
+	}
+	if len(r.trade.getBoughtInfo()) != 0 {
+		t.Fatal("position should be closed")
+	}
+	if sim.Balance("USDT") <= 100 {
+		t.Fatalf("expected profit, got balance %f", sim.Balance("USDT"))
+	}
+}
+
+func TestSimStopLoss(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.01, 1.00)
+	r := newSimRunner(t, sim, testSellOption)
+	r.run(context.Background())
+
+	bill := r.sold(t)
+	if bill.Reason != SellReasonForStopLoss {
+		t.Fatalf("expected stop loss, got %s", bill.Reason)
+	}
+	if sim.Balance("USDT") >= 100 {
+		t.Fatalf("expected loss, got balance %f", sim.Balance("USDT"))
+	}
+}
+
+func TestSimStopLossDuration(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.00, 1.00)
+	option := testSellOption
+	option.StopLossDuration = time.Hour
+	r := newSimRunner(t, sim, option)
+	r.run(context.Background())
+
+	if len(r.sells) != 0 {
+		t.Fatal("stop loss should wait for StopLossDuration")
+	}
+	if len(r.trade.getBoughtInfo()) != 1 {
+		t.Fatal("position should be kept")
+	}
+}
+
+func TestSimForceStopLoss(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 0.98)
+	option := testSellOption
+	option.StopLossDuration = time.Hour
+	r := newSimRunner(t, sim, option)
+	r.run(context.Background())
+
+	bill := r.sold(t)
+	if bill.Reason != SellReasonForForceStopLoss {
+		t.Fatalf("expected force stop loss, got %s", bill.Reason)
+	}
+}
+
+func TestSimTrailingTakeProfit(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.045, 1.05, 1.025)
+	option := testSellOption
+	option.EnableTrailingTakeProfit = true
+	option.TrailingTakeProfit = 2
+	option.TrailingStopLoss = 2
+	r := newSimRunner(t, sim, option)
+	r.run(context.Background())
+
+	// take profit moves to 4% and stop loss to 0.5% at 1.045, 1.025 falls under the new stop loss.
+	bill := r.sold(t)
+	if bill.Reason != SellReasonForStopLoss {
+		t.Fatalf("expected stop loss, got %s", bill.Reason)
+	}
+	if bill.Info.TakeProfit != 4 || *bill.Info.StopLoss != 0.5 {
+		t.Fatalf("unexpected trailing tp=%f sl=%f", bill.Info.TakeProfit, *bill.Info.StopLoss)
+	}
+	if sim.Balance("USDT") <= 100 {
+		t.Fatalf("expected profit, got balance %f", sim.Balance("USDT"))
+	}
+}
+
+func TestSimMaxBuyAndWhiteList(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02)
+	sim.SetPricePath("ETHUSDT", 2000, 2100)
+	sim.SetPricePath("BNBUSDT", 300, 302)
+	sim.SetPricePath("XRPUSDT", 1.00, 1.10)
+	r := newSimRunner(t, sim, testSellOption)
+	r.run(context.Background())
+
+	bought := r.trade.getBoughtInfo()
+	if len(bought) != 2 {
+		t.Fatalf("expected 2 positions, got %d", len(bought))
+	}
+	// the biggest changes are bought first.
+	if bought["ETHUSDT"] == nil || bought["DOGEUSDT"] == nil {
+		t.Fatalf("unexpected positions %v", bought)
+	}
+}
+
+func TestSimSameCoinBlock(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02, 1.05, 1.00, 1.02)
+	r := newSimRunner(t, sim, testSellOption)
+	option := r.trade.Option()
+	option.BuyOption.SameCoinBlockDuration = time.Hour
+	r.trade.option = option
+	r.run(context.Background())
+
+	r.sold(t)
+	if len(r.trade.getBoughtInfo()) != 0 {
+		t.Fatal("blocked symbol should not be bought again")
+	}
+}
+
+func TestSimFeeAndSlippage(t *testing.T) {
+	sim := newTestSim()
+	sim.Fee = 0.001
+	sim.Slippage = 0.01
+	sim.SetPricePath("DOGEUSDT", 1.00, 1.02)
+	r := newSimRunner(t, sim, testSellOption)
+	r.run(context.Background())
+
+	info := r.trade.getBoughtInfo()["DOGEUSDT"]
+	if info == nil {
+		t.Fatal("expected DOGEUSDT to be bought")
+	}
+	cost := info.ExecutedQuantity * 1.02 * 1.01
+	if diff := info.CummulativeQuoteQuantity - cost; diff > 1e-6 || diff < -1e-6 {
+		t.Fatalf("expected quote %f, got %f", cost, info.CummulativeQuoteQuantity)
+	}
+	if diff := 100 - cost*1.001 - sim.Balance("USDT"); diff > 1e-6 || diff < -1e-6 {
+		t.Fatalf("unexpected balance %f", sim.Balance("USDT"))
+	}
+}
@@ -0,0 +1,119 @@
+package trade
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// maxSellHistory how many sell bills we keep in memory
+const maxSellHistory = 200
+
+// Position defines a bought coin with its current price
+type Position struct {
+	*BoughtInfo
+
+	BuyPrice  float64   `json:"buyPrice"`
+	LastPrice float64   `json:"lastPrice"`
+	PriceTime time.Time `json:"priceTime"`
+
+	// TakeProfitPrice, StopLossPrice and ForceStopLossPrice the prices the position will be sold at, 0 if not set.
+	TakeProfitPrice    float64 `json:"takeProfitPrice"`
+	StopLossPrice      float64 `json:"stopLossPrice"`
+	ForceStopLossPrice float64 `json:"forceStopLossPrice"`
+
+	// UnrealisedPnL the QuoteAsset we earn if we sell at LastPrice, the buy commission included,
+	// UnrealisedPnLPercent in percent of the cost.
+	UnrealisedPnL        float64 `json:"unrealisedPnl"`
+	UnrealisedPnLPercent float64 `json:"unrealisedPnlPercent"`
+}
+
+// Positions returns the bought coins with current prices
+func (t *Trade) Positions(ctx context.Context) []*Position {
+	return t.positions(t.GetSymbolPrice(ctx, ""))
+}
+
+// CachedPositions returns the bought coins with the prices of the price book, no request is sent.
+// The book is kept up to date by the price stream, or by the checks polling the prices.
+func (t *Trade) CachedPositions() []*Position {
+	return t.positions(t.book.snapshot(""))
+}
+
+func (t *Trade) positions(prices map[string]*SymbolPrice) []*Position {
+	var (
+		bought    = t.getBoughtInfo()
+		positions = make([]*Position, 0, len(bought))
+	)
+	for symbol, info := range bought {
+		p := &Position{
+			BoughtInfo: info,
+			BuyPrice:   info.GetPrice(),
+		}
+		if info.ExecutedQuantity != 0 {
+			p.TakeProfitPrice = p.BuyPrice * (1 + info.TakeProfit/100)
+			if info.StopLoss != nil {
+				p.StopLossPrice = p.BuyPrice * (1 + *info.StopLoss/100)
+			}
+			if info.ForceStopLoss != nil {
+				p.ForceStopLossPrice = p.BuyPrice * (1 + *info.ForceStopLoss/100)
+			}
+		}
+		if pr, ok := prices[symbol]; ok && info.ExecutedQuantity != 0 && info.CummulativeQuoteQuantity != 0 {
+			p.LastPrice = pr.Price
+			p.PriceTime = pr.Time
+			p.UnrealisedPnL = pr.Price*info.ExecutedQuantity - info.CummulativeQuoteQuantity - info.Commission
+			p.UnrealisedPnLPercent = p.UnrealisedPnL / info.CummulativeQuoteQuantity * 100
+		}
+		positions = append(positions, p)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].Time.Before(positions[j].Time)
+	})
+	return positions
+}
+
+func (t *Trade) addSellHistory(bill *SellBill) {
+	t.historyMutex.Lock()
+	defer t.historyMutex.Unlock()
+
+	t.sellHistory = append(t.sellHistory, bill)
+	if len(t.sellHistory) > maxSellHistory {
+		t.sellHistory = t.sellHistory[len(t.sellHistory)-maxSellHistory:]
+	}
+}
+
+// SellHistory returns the latest sell bills, newest first
+func (t *Trade) SellHistory(limit int) []*SellBill {
+	t.historyMutex.Lock()
+	defer t.historyMutex.Unlock()
+
+	var bills []*SellBill
+	for i := len(t.sellHistory) - 1; i >= 0; i-- {
+		if limit > 0 && len(bills) >= limit {
+			break
+		}
+		bills = append(bills, t.sellHistory[i])
+	}
+	return bills
+}
+
+// BlockedSymbols returns the symbols we just sold and will not buy until the time
+func (t *Trade) BlockedSymbols() map[string]time.Time {
+	t.cacheMutex.Lock()
+	defer t.cacheMutex.Unlock()
+
+	var (
+		blocked = make(map[string]time.Time)
+		now     = t.now()
+	)
+	for _, key := range t.boughtCache.Keys() {
+		val, ok := t.boughtCache.Peek(key)
+		if !ok {
+			continue
+		}
+		if ti := val.(time.Time); ti.After(now) {
+			blocked[key.(string)] = ti
+		}
+	}
+	return blocked
+}
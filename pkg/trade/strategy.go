@@ -0,0 +1,124 @@
+package trade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultStrategy the strategy used when BuyOption.Strategy is empty
+const DefaultStrategy = "momentum"
+
+// Signal defines a symbol a strategy wants to buy, signals with higher Score are bought first.
+type Signal struct {
+	Symbol string
+	Price  float64
+	Score  float64
+}
+
+// Strategy decides what to buy. It observes the prices every BuyOption.Interval,
+// candidates are the symbols we may buy: in the white list and not blocked.
+// Strategies need candles build them from the observed prices.
+type Strategy interface {
+	Observe(ctx context.Context, prices map[string]*SymbolPrice, candidates []string) []Signal
+}
+
+// ExitStrategy is implemented by strategies owning exit rules, it is checked
+// every SellOption.Interval before the TP/SL rules.
+type ExitStrategy interface {
+	ShouldExit(info *BoughtInfo, price *SymbolPrice) bool
+}
+
+// StrategyFactory creates a strategy with the option of the trade.
+type StrategyFactory func(option Option) (Strategy, error)
+
+var (
+	strategiesMutex sync.RWMutex
+	strategies      = map[string]StrategyFactory{
+		DefaultStrategy: newMomentumStrategy,
+	}
+)
+
+// RegisterStrategy registers a strategy to be selected by BuyOption.Strategy,
+// it replaces the strategy with the same name.
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategiesMutex.Lock()
+	defer strategiesMutex.Unlock()
+	strategies[name] = factory
+}
+
+// Strategies returns the names of the registered strategies
+func Strategies() []string {
+	strategiesMutex.RLock()
+	defer strategiesMutex.RUnlock()
+
+	var names []string
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewStrategy creates the strategy registered with name.
+func NewStrategy(name string, option Option) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategiesMutex.RLock()
+	factory, ok := strategies[name]
+	strategiesMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q, registered: %v", name, Strategies())
+	}
+	return factory(option)
+}
+
+// momentumStrategy buys when price rose more than BuyOption.PriceUpChange percent since the previous check.
+type momentumStrategy struct {
+	up   *float64
+	last map[string]*SymbolPrice
+}
+
+func newMomentumStrategy(option Option) (Strategy, error) {
+	return &momentumStrategy{up: option.BuyOption.PriceUpChange}, nil
+}
+
+func (m *momentumStrategy) Observe(ctx context.Context, prices map[string]*SymbolPrice, candidates []string) []Signal {
+	var signals []Signal
+	if m.up != nil {
+		for _, symbol := range candidates {
+			pr, ok := m.last[symbol]
+			if !ok {
+				continue
+			}
+			now, ok := prices[symbol]
+			if !ok {
+				continue
+			}
+			change := (now.Price - pr.Price) / pr.Price * 100
+			if change > *m.up {
+				signals = append(signals, Signal{Symbol: symbol, Price: now.Price, Score: change})
+			}
+		}
+	}
+	if prices != nil {
+		m.last = prices
+	}
+	return signals
+}
+
+// Strategy returns the strategy the trade buys with
+func (t *Trade) Strategy() Strategy {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.strategy
+}
+
+// SetStrategy replaces the strategy the trade buys with
+func (t *Trade) SetStrategy(s Strategy) {
+	t.mu.Lock()
+	t.strategy = s
+	t.mu.Unlock()
+}
@@ -0,0 +1,56 @@
+package trade
+
+import (
+	"context"
+	"testing"
+)
+
+// dipStrategy buys ETHUSDT below 1 and exits at 0.99, before the take profit price.
+type dipStrategy struct{}
+
+func (dipStrategy) Observe(ctx context.Context, prices map[string]*SymbolPrice, candidates []string) []Signal {
+	var signals []Signal
+	for _, symbol := range candidates {
+		if pr := prices[symbol]; symbol == "ETHUSDT" && pr.Price < 1 {
+			signals = append(signals, Signal{Symbol: symbol, Price: pr.Price, Score: 1 - pr.Price})
+		}
+	}
+	return signals
+}
+
+func (dipStrategy) ShouldExit(info *BoughtInfo, price *SymbolPrice) bool {
+	return price.Price >= 0.99
+}
+
+func TestNewStrategy(t *testing.T) {
+	if _, err := NewStrategy("", Option{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStrategy("nope", Option{}); err == nil {
+		t.Fatal("expected unknown strategy error")
+	}
+
+	RegisterStrategy("dip", func(option Option) (Strategy, error) {
+		return dipStrategy{}, nil
+	})
+	s, err := NewStrategy("dip", Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(ExitStrategy); !ok {
+		t.Fatal("expected exit rules")
+	}
+}
+
+func TestSimStrategyExit(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPricePath("ETHUSDT", 1.00, 0.98, 0.99)
+	r := newSimRunner(t, sim, testSellOption)
+	r.trade.SetStrategy(dipStrategy{})
+	r.run(context.Background())
+
+	bill := r.sold(t)
+	if bill.Info.Symbol != "ETHUSDT" || bill.Reason != SellReasonStrategy {
+		t.Fatalf("expected ETHUSDT sold by strategy, got %s %s", bill.Info.Symbol, bill.Reason)
+	}
+}
@@ -0,0 +1,299 @@
+package trade
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/adshao/go-binance/v2"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultStreamURL        = "wss://stream.binance.com:9443"
+	DefaultStreamStaleAfter = 10 * time.Second
+
+	maxStreamBackoff = 30 * time.Second
+
+	// binance retired the all market !bookTicker, the book of each symbol we may buy is subscribed instead.
+	// The symbols are resubscribed every streamSubscribeInterval, a connection takes at most maxBookStreams streams.
+	streamSubscribeInterval = time.Minute
+	maxBookStreams          = 1000
+)
+
+// priceBook is the in-memory latest prices shared by the buy and TP/SL checks.
+type priceBook struct {
+	mu      sync.RWMutex
+	prices  map[string]*SymbolPrice
+	updated time.Time
+}
+
+func newPriceBook() *priceBook {
+	return &priceBook{prices: make(map[string]*SymbolPrice)}
+}
+
+// snapshot returns a copy of the prices, or only the symbol's if symbol is not empty.
+func (b *priceBook) snapshot(symbol string) map[string]*SymbolPrice {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var prs = make(map[string]*SymbolPrice)
+	for s, pr := range b.prices {
+		if symbol != "" && s != symbol {
+			continue
+		}
+		p := *pr
+		prs[s] = &p
+	}
+	return prs
+}
+
+func (b *priceBook) lastUpdate() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.updated
+}
+
+func (b *priceBook) setPrice(symbol string, price float64, ti time.Time) {
+	pr, ok := b.prices[symbol]
+	if !ok {
+		pr = &SymbolPrice{Symbol: symbol}
+		b.prices[symbol] = pr
+	}
+	pr.Price = price
+	pr.Time = ti
+}
+
+func (b *priceBook) setBook(symbol string, bid, ask float64) {
+	pr, ok := b.prices[symbol]
+	if !ok {
+		// no trade yet, use the middle of the book.
+		pr = &SymbolPrice{Symbol: symbol, Price: (bid + ask) / 2}
+		b.prices[symbol] = pr
+	}
+	pr.Bid = bid
+	pr.Ask = ask
+}
+
+// priceStream keeps the priceBook up to date with binance's combined !miniTicker@arr stream
+// and the <symbol>@bookTicker streams of symbols, it reconnects with backoff
+// and reports stale once no message arrived within staleAfter.
+type priceStream struct {
+	book       *priceBook
+	url        string
+	staleAfter time.Duration
+	dialer     *websocket.Dialer
+	logger     logrus.FieldLogger
+
+	// symbols returns the symbols the books are subscribed of, nil subscribes none.
+	symbols func() []string
+
+	// onReconnect is called after a reconnection, the prices missed in the gap are polled by REST.
+	onReconnect func(ctx context.Context)
+}
+
+func newPriceStream(book *priceBook, option SystemOption, logger logrus.FieldLogger) *priceStream {
+	s := &priceStream{
+		book:       book,
+		url:        option.PriceStreamURL,
+		staleAfter: option.PriceStreamStaleAfter,
+		dialer:     &websocket.Dialer{HandshakeTimeout: 30 * time.Second},
+		logger:     logger,
+	}
+	if s.url == "" {
+		s.url = DefaultStreamURL
+	}
+	if s.staleAfter <= 0 {
+		s.staleAfter = DefaultStreamStaleAfter
+	}
+	if option.ProxyURL != "" {
+		if proxyURL, err := url.Parse(option.ProxyURL); err == nil {
+			s.dialer.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	return s
+}
+
+// fresh reports whether the stream received a message recently.
+func (s *priceStream) fresh() bool {
+	return time.Since(s.book.lastUpdate()) < s.staleAfter
+}
+
+func (s *priceStream) run(ctx context.Context) {
+	var (
+		backoff   = time.Second
+		reconnect bool
+	)
+	for {
+		start := time.Now()
+		err := s.serve(ctx, reconnect)
+		if ctx.Err() != nil {
+			return
+		}
+		reconnect = true
+		if time.Since(start) > maxStreamBackoff {
+			backoff = time.Second
+		}
+		s.logger.WithError(err).Warnf("price stream disconnected, reconnect in %s, polling prices meanwhile", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxStreamBackoff {
+			backoff = maxStreamBackoff
+		}
+	}
+}
+
+type streamMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func (s *priceStream) serve(ctx context.Context, reconnect bool) error {
+	conn, _, err := s.dialer.Dial(s.url+"/stream?streams=!miniTicker@arr", nil)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// the only writer of conn, it keeps the subscribed books in line with the symbols.
+		subscribed := make(map[string]bool)
+		ticker := time.NewTicker(streamSubscribeInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.subscribe(conn, subscribed); err != nil {
+				s.logger.WithError(err).Warn("failed to subscribe book tickers")
+			}
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				conn.Close()
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	if reconnect && s.onReconnect != nil {
+		s.onReconnect(ctx)
+	}
+
+	for {
+		// gap detection, a silent connection is treated as broken.
+		conn.SetReadDeadline(time.Now().Add(s.staleAfter))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var msg streamMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return fmt.Errorf("invalid stream message: %v", err)
+		}
+		if err := s.handle(msg); err != nil {
+			s.logger.WithError(err).Debugf("failed to handle stream %s", msg.Stream)
+		}
+	}
+}
+
+// subscribe sends the changes of the book streams since subscribed, which is updated.
+func (s *priceStream) subscribe(conn *websocket.Conn, subscribed map[string]bool) error {
+	if s.symbols == nil {
+		return nil
+	}
+	want := make(map[string]bool)
+	for _, symbol := range s.symbols() {
+		if len(want) == maxBookStreams {
+			break
+		}
+		want[strings.ToLower(symbol)+"@bookTicker"] = true
+	}
+	var add, remove []string
+	for stream := range want {
+		if !subscribed[stream] {
+			add = append(add, stream)
+		}
+	}
+	for stream := range subscribed {
+		if !want[stream] {
+			remove = append(remove, stream)
+		}
+	}
+	for i, change := range []struct {
+		method  string
+		streams []string
+	}{{"UNSUBSCRIBE", remove}, {"SUBSCRIBE", add}} {
+		if len(change.streams) == 0 {
+			continue
+		}
+		sort.Strings(change.streams)
+		req := map[string]interface{}{"method": change.method, "params": change.streams, "id": i + 1}
+		if err := conn.WriteJSON(req); err != nil {
+			return err
+		}
+		for _, stream := range change.streams {
+			if change.method == "SUBSCRIBE" {
+				subscribed[stream] = true
+			} else {
+				delete(subscribed, stream)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *priceStream) handle(msg streamMessage) error {
+	switch msg.Stream {
+	case "!miniTicker@arr":
+		var event binance.WsAllMiniMarketsStatEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			return err
+		}
+		s.book.mu.Lock()
+		defer s.book.mu.Unlock()
+		for _, e := range event {
+			price, err := strconv.ParseFloat(e.LastPrice, 64)
+			if err != nil {
+				continue
+			}
+			s.book.setPrice(e.Symbol, price, time.Unix(0, e.Time*int64(time.Millisecond)))
+		}
+		s.book.updated = time.Now()
+	default:
+		if !strings.HasSuffix(msg.Stream, "@bookTicker") {
+			// eg: the results of SUBSCRIBE.
+			return nil
+		}
+		var event binance.WsBookTickerEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			return err
+		}
+		bid, err := strconv.ParseFloat(event.BestBidPrice, 64)
+		if err != nil {
+			return err
+		}
+		ask, err := strconv.ParseFloat(event.BestAskPrice, 64)
+		if err != nil {
+			return err
+		}
+		s.book.mu.Lock()
+		defer s.book.mu.Unlock()
+		s.book.setBook(event.Symbol, bid, ask)
+		s.book.updated = time.Now()
+	}
+	return nil
+}
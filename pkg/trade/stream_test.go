@@ -0,0 +1,98 @@
+package trade
+
+import (
+	"context"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPriceStream(t *testing.T) {
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("streams") != "!miniTicker@arr" {
+			t.Errorf("unexpected streams %s", r.URL.RawQuery)
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"!miniTicker@arr","data":[{"e":"24hrMiniTicker","E":1620000000000,"s":"DOGEUSDT","c":"0.50"}]}`))
+		// the books are subscribed by symbol.
+		var req struct {
+			Method string   `json:"method"`
+			Params []string `json:"params"`
+		}
+		if err := conn.ReadJSON(&req); err != nil || req.Method != "SUBSCRIBE" || len(req.Params) != 1 || req.Params[0] != "dogeusdt@bookTicker" {
+			t.Errorf("unexpected subscribe %+v: %v", req, err)
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"result":null,"id":2}`))
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"stream":"dogeusdt@bookTicker","data":{"u":1,"s":"DOGEUSDT","b":"0.49","B":"10","a":"0.51","A":"10"}}`))
+		// keep silent, the stream should be stale.
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	book := newPriceBook()
+	stream := newPriceStream(book, SystemOption{
+		PriceStreamURL:        "ws" + strings.TrimPrefix(server.URL, "http"),
+		PriceStreamStaleAfter: 200 * time.Millisecond,
+	}, logrus.New())
+	stream.symbols = func() []string { return []string{"DOGEUSDT"} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.run(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		pr := book.snapshot("DOGEUSDT")["DOGEUSDT"]
+		if pr != nil && pr.Ask != 0 {
+			if pr.Price != 0.5 || pr.Bid != 0.49 || pr.Ask != 0.51 {
+				t.Fatalf("unexpected price %+v", pr)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the price from stream")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !stream.fresh() {
+		t.Fatal("stream should be fresh")
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if stream.fresh() {
+		t.Fatal("stream should be stale")
+	}
+}
+
+func TestCachedPositions(t *testing.T) {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1)
+	r := newSimRunner(t, sim, testSellOption)
+	ctx := context.Background()
+	if _, err := r.trade.buyPosition(ctx, "DOGEUSDT", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := &countingExchange{Exchange: sim}
+	r.trade.SetExchange(ex)
+	r.trade.book.mu.Lock()
+	r.trade.book.setPrice("DOGEUSDT", 1.1, time.Now())
+	r.trade.book.mu.Unlock()
+
+	positions := r.trade.CachedPositions()
+	if len(positions) != 1 || positions[0].LastPrice != 1.1 || positions[0].UnrealisedPnL <= 0 {
+		t.Fatalf("unexpected positions %+v", positions)
+	}
+	if ex.listPrices != 0 {
+		t.Fatalf("expected no prices requested, got %d", ex.listPrices)
+	}
+}
@@ -34,7 +34,46 @@ type Trade struct {
 	mu     sync.RWMutex
 	option Option
 
-	client *binance.Client
+	// configFile the config file watched by WatchConfig
+	configFile string
+
+	// reloadMutex serializes reloads, reloadChan tells the watch loop the option is reloaded.
+	reloadMutex sync.Mutex
+	reloadChan  chan struct{}
+
+	exchange Exchange
+
+	// exchangeInfo the cached exchange info of exchange
+	exchangeInfo *exchangeInfoCache
+
+	// limiter the request weight budget of the binance client
+	limiter *RateLimiter
+
+	// universe the symbols we may buy, rebuilt with the exchange info and the option.
+	universeMutex sync.Mutex
+	universe      *universe
+
+	// paper the virtual account of paper trading
+	paper *SimExchange
+
+	book   *priceBook
+	stream *priceStream
+
+	journal *Journal
+
+	// strategy decides what to buy, see BuyOption.Strategy
+	strategy Strategy
+
+	// ocoChecked when the OCO of each symbol is queried last, see shouldReconcileOCO
+	ocoMutex   sync.Mutex
+	ocoChecked map[string]ocoCheck
+
+	// dust the positions too small to sell already reported, by symbol
+	dustMutex sync.Mutex
+	dust      map[string]int64
+
+	// pnl the realised PnL of the sold positions
+	pnl *pnlBook
 
 	closers []io.Closer
 
@@ -43,16 +82,31 @@ type Trade struct {
 	boughtMutex sync.Mutex
 	boughtInfo  map[string]*BoughtInfo
 
+	// buyMutex serializes buying, so MaxBuy holds with manual buys.
+	buyMutex sync.Mutex
+
+	// paused stops buying, TP/SL checks keep running.
+	paused int32
+
 	sellChan chan *SellBill
 
-	buyChan chan []*symbolPriceChange
+	buyChan chan []Signal
 
 	cacheMutex  sync.Mutex
 	boughtCache *lru.Cache
 
+	historyMutex sync.Mutex
+	sellHistory  []*SellBill
+
+	hookMutex sync.Mutex
+	hooks     []Hook
+
 	AfterSell func(info *SellBill)
 
 	AfterBuy func(order *binance.Order)
+
+	// clock returns the current time, backtests replace it with a virtual clock.
+	clock func() time.Time
 }
 
 // NewTrade returns Trade object.
@@ -68,7 +122,14 @@ func NewTrade(opts ...Options) *Trade {
 	t.boughtCache = cache
 	t.option = option
 	t.boughtInfo = make(map[string]*BoughtInfo)
+	t.ocoChecked = make(map[string]ocoCheck)
+	t.dust = make(map[string]int64)
 	t.sellChan = make(chan *SellBill, 60)
+	t.buyChan = make(chan []Signal, 1)
+	t.reloadChan = make(chan struct{}, 1)
+	t.book = newPriceBook()
+	t.pnl = newPnLBook()
+	t.exchangeInfo = newExchangeInfoCache()
 	t.SetSystemOption(option.SystemOption)
 	t.init()
 
@@ -98,12 +159,46 @@ func (t *Trade) SetSystemOption(option SystemOption) {
 			Proxy: http.ProxyURL(proxyURL),
 		}
 	}
+	t.limiter = NewRateLimiter(t.option.SystemOption.WeightLimit, t.option.SystemOption.OrderLimit)
+	t.limiter.observe = func(weight, orders int) {
+		requestWeightUsed.WithLabelValues(option.Name).Set(float64(weight))
+		ordersUsed.WithLabelValues(option.Name).Set(float64(orders))
+	}
+	client.Transport = t.limiter.Transport(client.Transport)
 
 	bclient := binance.NewClient(t.option.SystemOption.AccessKey, t.option.SystemOption.SecretKey)
 	bclient.HTTPClient = client
 	bclient.Debug = t.option.SystemOption.Debug
 
-	t.client = bclient
+	t.exchange = NewBinanceExchange(bclient)
+	t.paper = nil
+
+	if t.option.SystemOption.PaperTrading {
+		balance := t.option.SystemOption.PaperBalance
+		if balance == 0 {
+			balance = 1000
+		}
+		balances := map[string]float64{t.option.BuyOption.MainCoin: balance}
+		for _, q := range t.option.BuyOption.quotes() {
+			if _, ok := balances[q.Coin]; !ok {
+				balances[q.Coin] = q.MoneyPerOrder * float64(q.MaxBuy)
+			}
+		}
+		t.paper = NewSimExchange(balances)
+		t.paper.Fee = t.option.SystemOption.PaperFee
+		t.paper.Slippage = t.option.SystemOption.PaperSlippage
+		t.exchange = NewPaperExchange(t.exchange, t.paper)
+	}
+	t.exchange = newMetricsExchange(t.exchange, option.Name)
+	t.exchangeInfo.reset()
+	t.resetUniverse()
+}
+
+// SetExchange replace the backend Trade talks to, goroutine unsafe
+func (t *Trade) SetExchange(exchange Exchange) {
+	t.exchange = newMetricsExchange(exchange, t.option.SystemOption.Name)
+	t.exchangeInfo.reset()
+	t.resetUniverse()
 }
 
 func (t *Trade) init() {
@@ -116,7 +211,6 @@ func (t *Trade) init() {
 		fi, err := os.OpenFile(t.option.SystemOption.LogFile, os.O_CREATE|os.O_APPEND, 0777)
 		if err != nil {
 			panic(err)
-			return
 		}
 		t.closers = append(t.closers, fi)
 		l.SetOutput(fi)
@@ -124,28 +218,92 @@ func (t *Trade) init() {
 	if t.option.SystemOption.Debug {
 		l.SetLevel(logrus.DebugLevel)
 	}
+	if t.option.SystemOption.Name != "" {
+		t.logger = l.WithField("bot", t.option.SystemOption.Name)
+	}
 
 	// Reset Bought Info
-	if t.option.BuyOption.BoughtFile != "" {
-		data, err := ioutil.ReadFile(t.option.BuyOption.BoughtFile)
+	boughtFile := t.option.boughtFile()
+	if boughtFile != "" {
+		data, err := ioutil.ReadFile(boughtFile)
 		if err != nil {
 			if !os.IsNotExist(err) {
 				panic(err)
 			}
 		}
 		json.Unmarshal(data, &t.boughtInfo)
+		// positions saved before the assets are recorded, the quote tells them apart from now on.
+		for _, info := range t.boughtInfo {
+			info.BaseAsset, info.QuoteAsset = info.assets(t.option.BuyOption)
+		}
+	}
+
+	if journalFile := t.option.journalFile(); journalFile != "" {
+		journal, err := OpenJournal(journalFile)
+		if err != nil {
+			panic(err)
+		}
+		t.journal = journal
+		t.closers = append(t.closers, journal)
+		if boughtFile != "" {
+			count, err := journal.ImportBoughtFile(boughtFile)
+			if err != nil {
+				t.logger.WithError(err).Error("failed to import bought file to journal")
+			} else if count > 0 {
+				t.logger.Infof("imported %d positions from %s to journal", count, boughtFile)
+			}
+		}
+		if err := t.loadPnL(); err != nil {
+			t.logger.WithError(err).Error("failed to load pnl from journal")
+		}
+	}
+
+	strategy, err := NewStrategy(t.option.BuyOption.Strategy, t.option)
+	if err != nil {
+		panic(err)
 	}
+	t.strategy = strategy
+
+	if t.option.SystemOption.PriceStream {
+		t.stream = newPriceStream(t.book, t.option.SystemOption, t.logger)
+		// the bids of the symbols we may buy, for limit entries.
+		t.stream.symbols = t.Universe
+		t.stream.onReconnect = func(ctx context.Context) {
+			t.pollSymbolPrice(ctx, "")
+		}
+	}
+
+	if t.paper != nil {
+		t.logger.Warnf("paper trading enabled, orders will not be sent to binance, positions are saved in %s", boughtFile)
+		// the virtual balance restarts on each start, give back the coins of the saved positions.
+		for _, info := range t.boughtInfo {
+			base, _ := info.assets(t.option.BuyOption)
+			t.paper.Deposit(base, info.ExecutedQuantity)
+		}
+	}
+	t.updatePositionMetrics()
 }
 
 func (t *Trade) Run(stopChan chan struct{}) error {
-	if t.client == nil {
-		return errors.New("client not init")
+	if t.exchange == nil {
+		return errors.New("exchange not init")
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	if err := t.RefreshExchangeInfo(ctx); err != nil {
+		t.logger.WithError(err).Error("failed to load exchange info, it is loaded on the first order")
+	} else if err := t.validateMinNotional(t.Option()); err != nil {
+		cancel()
+		return err
+	}
+	go t.runExchangeInfo(ctx)
 	go t.runBuy(ctx)
 	go t.runSell(ctx)
+	go t.watchPrice(ctx)
+	if t.stream != nil {
+		go t.stream.run(ctx)
+	}
 
 	go func() {
 		<-stopChan
@@ -165,6 +323,9 @@ func (t *Trade) Close() error {
 }
 
 func (t *Trade) getBoughtInfo() map[string]*BoughtInfo {
+	t.boughtMutex.Lock()
+	defer t.boughtMutex.Unlock()
+
 	var info = make(map[string]*BoughtInfo)
 	for k, v := range t.boughtInfo {
 		i := BoughtInfo{}
@@ -174,6 +335,24 @@ func (t *Trade) getBoughtInfo() map[string]*BoughtInfo {
 	return info
 }
 
+// updateBoughtInfo write back the copy returned by getBoughtInfo, if it is still bought.
+func (t *Trade) updateBoughtInfo(info *BoughtInfo) {
+	t.boughtMutex.Lock()
+	defer t.boughtMutex.Unlock()
+
+	if old, ok := t.boughtInfo[info.Symbol]; ok && old.OrderId == info.OrderId {
+		i := *info
+		t.boughtInfo[info.Symbol] = &i
+	}
+}
+
+func (t *Trade) now() time.Time {
+	if t.clock != nil {
+		return t.clock()
+	}
+	return time.Now()
+}
+
 func (t *Trade) Option() Option {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -182,14 +361,13 @@ func (t *Trade) Option() Option {
 }
 
 func (t *Trade) save() {
-	option := t.Option()
-	if option.BuyOption.BoughtFile != "" {
+	if file := t.Option().boughtFile(); file != "" {
 		t.boughtMutex.Lock()
 		data, err := json.Marshal(t.boughtInfo)
 		t.boughtMutex.Unlock()
 		if err != nil {
 			return
 		}
-		ioutil.WriteFile(option.BuyOption.BoughtFile, data, 0777)
+		ioutil.WriteFile(file, data, 0777)
 	}
 }
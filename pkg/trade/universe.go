@@ -0,0 +1,193 @@
+package trade
+
+import (
+	"github.com/adshao/go-binance/v2"
+	"path"
+	"sort"
+	"strings"
+)
+
+// universe the symbols the trade may buy
+type universe struct {
+	symbols map[string]bool
+}
+
+// matchSymbol reports whether a pattern matches the base asset or the symbol,
+// patterns are globs like "*UP" or "BTC*", matched case-insensitively.
+func matchSymbol(patterns []string, base, symbol string) bool {
+	for _, p := range patterns {
+		p = strings.ToUpper(p)
+		if ok, _ := path.Match(p, base); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, symbol); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultExcludes tells whether the black list and leveraged tokens are excluded, only the universe
+// is filtered by default, coins white listed explicitly are bought.
+func (b BuyOption) defaultExcludes() bool {
+	return b.Exclude == nil && b.Universe
+}
+
+// excludes returns the exclude patterns of the option.
+func (b BuyOption) excludes() []string {
+	if b.defaultExcludes() {
+		return blackList
+	}
+	return b.Exclude
+}
+
+// leveragedToken reports whether base is a leveraged token, eg: BTCUP with BTCDOWN listed too,
+// coins merely ending with UP like JUP have no such pair.
+func leveragedToken(base string, bases map[string]bool) bool {
+	for _, pair := range [][2]string{{"UP", "DOWN"}, {"BULL", "BEAR"}} {
+		for i, suffix := range pair {
+			if len(base) > len(suffix) && strings.HasSuffix(base, suffix) && bases[strings.TrimSuffix(base, suffix)+pair[1-i]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BuildUniverse returns the symbols we may buy, sorted. With BuyOption.Universe it is every symbol
+// of the exchange info trading against one of the quotes on spot, else the WhiteList. Symbols matching
+// BuyOption.Include if it is set, and not matching BuyOption.Exclude are kept.
+// info may be nil, the WhiteList is not checked against the exchange then.
+func BuildUniverse(info *binance.ExchangeInfo, option BuyOption) []string {
+	var (
+		symbols = make(map[string]string)
+		listed  = make(map[string]binance.Symbol)
+		bases   = make(map[string]bool)
+	)
+	if info != nil {
+		for _, s := range info.Symbols {
+			listed[s.Symbol] = s
+			bases[s.BaseAsset] = true
+		}
+	}
+	if option.Universe {
+		for _, s := range listed {
+			symbols[s.Symbol] = s.BaseAsset
+		}
+	} else {
+		for _, q := range option.quotes() {
+			for _, coin := range option.WhiteList {
+				symbols[coin+q.Coin] = coin
+			}
+		}
+	}
+
+	var res []string
+	for symbol, base := range symbols {
+		if s, ok := listed[symbol]; ok {
+			if _, ok := option.quote(s.QuoteAsset); !ok || s.Status != string(binance.SymbolStatusTypeTrading) || !s.IsSpotTradingAllowed {
+				continue
+			}
+		} else if info != nil {
+			// delisted, eg: BCC and VEN of the default white list.
+			continue
+		}
+		if len(option.Include) > 0 && !matchSymbol(option.Include, base, symbol) {
+			continue
+		}
+		if matchSymbol(option.excludes(), base, symbol) {
+			continue
+		}
+		if option.defaultExcludes() && leveragedToken(base, bases) {
+			continue
+		}
+		res = append(res, symbol)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// rebuildUniverse builds the universe with the cached exchange info, and logs the changes.
+func (t *Trade) rebuildUniverse() *universe {
+	option := t.Option()
+
+	c := t.exchangeInfo
+	c.mu.RLock()
+	symbols := BuildUniverse(c.info, option.BuyOption)
+	if option.BuyOption.Universe {
+		// a symbol we can not buy MoneyPerOrder of is useless.
+		kept := symbols[:0]
+		for _, s := range symbols {
+			if r, ok := c.rules[s]; ok {
+				if q, ok := option.BuyOption.quote(r.QuoteAsset); ok && r.MinNotional > q.MoneyPerOrder {
+					continue
+				}
+			}
+			kept = append(kept, s)
+		}
+		symbols = kept
+	}
+	c.mu.RUnlock()
+
+	u := &universe{symbols: make(map[string]bool, len(symbols))}
+	for _, s := range symbols {
+		u.symbols[s] = true
+	}
+
+	t.universeMutex.Lock()
+	old := t.universe
+	t.universe = u
+	t.universeMutex.Unlock()
+
+	if old != nil {
+		var added, removed []string
+		for s := range u.symbols {
+			if !old.symbols[s] {
+				added = append(added, s)
+			}
+		}
+		for s := range old.symbols {
+			if !u.symbols[s] {
+				removed = append(removed, s)
+			}
+		}
+		if len(added) > 0 || len(removed) > 0 {
+			sort.Strings(added)
+			sort.Strings(removed)
+			t.logger.Infof("symbol universe changed: %d symbols, added %v, removed %v", len(u.symbols), added, removed)
+		}
+	}
+	return u
+}
+
+// resetUniverse drops the universe, it is built again on the next lookup.
+func (t *Trade) resetUniverse() {
+	t.universeMutex.Lock()
+	t.universe = nil
+	t.universeMutex.Unlock()
+}
+
+// Universe returns the symbols the trade may buy, sorted.
+func (t *Trade) Universe() []string {
+	var res []string
+	for s := range t.getUniverse().symbols {
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func (t *Trade) getUniverse() *universe {
+	t.universeMutex.Lock()
+	u := t.universe
+	t.universeMutex.Unlock()
+	if u == nil {
+		u = t.rebuildUniverse()
+	}
+	return u
+}
+
+// inUniverse reports whether we may buy the symbol
+func (t *Trade) inUniverse(symbol string) bool {
+	return t.getUniverse().symbols[symbol]
+}
@@ -0,0 +1,82 @@
+package trade
+
+import (
+	"github.com/adshao/go-binance/v2"
+	"reflect"
+	"testing"
+)
+
+func universeSymbol(base, quote, status string) binance.Symbol {
+	return binance.Symbol{
+		Symbol:               base + quote,
+		BaseAsset:            base,
+		QuoteAsset:           quote,
+		Status:               status,
+		IsSpotTradingAllowed: true,
+	}
+}
+
+func TestBuildUniverse(t *testing.T) {
+	info := &binance.ExchangeInfo{Symbols: []binance.Symbol{
+		universeSymbol("BTC", "USDT", "TRADING"),
+		universeSymbol("ETH", "USDT", "TRADING"),
+		universeSymbol("DOGE", "USDT", "TRADING"),
+		universeSymbol("ETHUP", "USDT", "TRADING"),
+		universeSymbol("ETHDOWN", "USDT", "TRADING"),
+		universeSymbol("JUP", "USDT", "TRADING"),
+		universeSymbol("USDC", "USDT", "TRADING"),
+		universeSymbol("LUNA", "USDT", "BREAK"),
+		universeSymbol("ETH", "BTC", "TRADING"),
+	}}
+	margin := universeSymbol("ADA", "USDT", "TRADING")
+	margin.IsSpotTradingAllowed = false
+	info.Symbols = append(info.Symbols, margin)
+
+	tests := []struct {
+		name   string
+		option BuyOption
+		want   []string
+	}{
+		{
+			name:   "universe with the default exclude",
+			option: BuyOption{MainCoin: "USDT", Universe: true},
+			want:   []string{"BTCUSDT", "DOGEUSDT", "ETHUSDT", "JUPUSDT"},
+		},
+		{
+			name:   "universe with include",
+			option: BuyOption{MainCoin: "USDT", Universe: true, Include: []string{"eth*", "DOGE"}},
+			want:   []string{"DOGEUSDT", "ETHUSDT"},
+		},
+		{
+			name:   "empty exclude turns off the black list",
+			option: BuyOption{MainCoin: "USDT", Universe: true, Exclude: []string{}},
+			want:   []string{"BTCUSDT", "DOGEUSDT", "ETHDOWNUSDT", "ETHUPUSDT", "ETHUSDT", "JUPUSDT", "USDCUSDT"},
+		},
+		{
+			name:   "white list drops delisted and halted symbols",
+			option: BuyOption{MainCoin: "USDT", WhiteList: []string{"BTC", "VEN", "LUNA"}},
+			want:   []string{"BTCUSDT"},
+		},
+		{
+			name:   "white list is not filtered by default",
+			option: BuyOption{MainCoin: "USDT", WhiteList: []string{"ETHUP", "USDC", "JUP"}},
+			want:   []string{"ETHUPUSDT", "JUPUSDT", "USDCUSDT"},
+		},
+		{
+			name:   "other quote",
+			option: BuyOption{MainCoin: "BTC", Universe: true},
+			want:   []string{"ETHBTC"},
+		},
+	}
+	for _, tt := range tests {
+		if got := BuildUniverse(info, tt.option); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	// the white list is trusted while the exchange info is not loaded.
+	got := BuildUniverse(nil, BuyOption{MainCoin: "USDT", WhiteList: []string{"VEN", "BTC"}})
+	if want := []string{"BTCUSDT", "VENUSDT"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
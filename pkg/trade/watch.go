@@ -9,26 +9,45 @@ import (
 func (t *Trade) watchPrice(ctx context.Context) {
 	var (
 		option          = t.Option()
-		sellCheckTicker = time.NewTicker(option.SellOption.Interval)
-		buyCheckTicker  = time.NewTicker(option.BuyOption.Interval)
-		lastPrice       map[string]*SymbolPrice
+		sellInterval    = option.SellOption.Interval
+		buyInterval     = option.BuyOption.Interval
+		sellCheckTicker = time.NewTicker(sellInterval)
+		buyCheckTicker  = time.NewTicker(buyInterval)
 	)
+	defer func() {
+		sellCheckTicker.Stop()
+		buyCheckTicker.Stop()
+	}()
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-t.reloadChan:
+			// the option is reloaded, recreate the tickers of changed intervals.
+			option := t.Option()
+			if option.BuyOption.Interval != buyInterval {
+				buyInterval = option.BuyOption.Interval
+				buyCheckTicker.Stop()
+				buyCheckTicker = time.NewTicker(buyInterval)
+			}
+			if option.SellOption.Interval != sellInterval {
+				sellInterval = option.SellOption.Interval
+				sellCheckTicker.Stop()
+				sellCheckTicker = time.NewTicker(sellInterval)
+			}
 		case <-buyCheckTicker.C:
+			start := time.Now()
 			t.mu.Lock()
 			option := t.option
 			t.mu.Unlock()
-			nowPrice, change := t.checkingPrice(ctx, option, lastPrice)
-			if nowPrice != nil {
-				lastPrice = nowPrice
-			}
-			if change != nil {
-				t.buyChan <- change
+			signals := t.checkingPrice(ctx, option)
+			t.updatePriceStaleness()
+			t.observeLoop("watch_buy", start)
+			if signals != nil && !t.Paused() {
+				t.offerSignals(signals)
 			}
 		case <-sellCheckTicker.C:
+			start := time.Now()
 			t.mu.Lock()
 			option := t.option
 			t.mu.Unlock()
@@ -36,73 +55,64 @@ func (t *Trade) watchPrice(ctx context.Context) {
 			if err := t.checkingTPSL(ctx, option); err != nil {
 				t.logger.WithError(err).Error("failed to checking TP/SL")
 			}
+			t.observeLoop("watch_sell", start)
 		}
 	}
 }
 
-type symbolPriceChange struct {
-	symbol    string
-	lastPrice float64
-	nowPrice  float64
-	change    float64
-	volume    float64
+// offerSignals hands the signals to runBuy, they are dropped if it is still busy with the last ones,
+// eg: a limit entry, so the TP/SL checks of this loop are never held up by buying.
+func (t *Trade) offerSignals(signals []Signal) bool {
+	select {
+	case t.buyChan <- signals:
+		return true
+	default:
+		t.logger.Debugf("still buying, %d signals dropped", len(signals))
+		return false
+	}
 }
 
-func (t *Trade) checkingPrice(ctx context.Context, option Option, lastPrice map[string]*SymbolPrice) (map[string]*SymbolPrice, []*symbolPriceChange) {
-	var changes []*symbolPriceChange
-	nowPrice := t.GetSymbolPrice(ctx, "")
-	for symbol, pr := range lastPrice {
-		// check if symbol is in white list
-		if !option.BuyOption.InWhiteList(symbol) {
+// checkingPrice asks the strategy what to buy with the current prices.
+func (t *Trade) checkingPrice(ctx context.Context, option Option) []Signal {
+	prices := t.GetSymbolPrice(ctx, "")
+	var candidates []string
+	for symbol := range prices {
+		// check if symbol is in the universe
+		if !t.inUniverse(symbol) {
 			continue
 		}
 		// check if symbol in buy blocks
 		if t.isBlock(symbol) {
 			continue
 		}
-
-		now, ok := nowPrice[symbol]
-		if !ok {
-			continue
-		}
-		var shouldBuy bool
-		change := (now.Price - pr.Price) / pr.Price * 100
-		if option.BuyOption.PriceUpChange != nil {
-			up := *option.BuyOption.PriceUpChange
-			if change > up {
-				shouldBuy = true
-			}
-		}
-		if shouldBuy {
-			changes = append(changes, &symbolPriceChange{
-				symbol:    symbol,
-				lastPrice: pr.Price,
-				nowPrice:  now.Price,
-				change:    change,
-			})
-		}
+		candidates = append(candidates, symbol)
 	}
+	sort.Strings(candidates)
 
-	sort.Slice(changes, func(i, j int) bool {
-		return changes[i].change > changes[j].change
+	signals := t.Strategy().Observe(ctx, prices, candidates)
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].Score > signals[j].Score
 	})
-
-	return nowPrice, changes
+	return signals
 }
 
 func (t *Trade) checkingTPSL(ctx context.Context, option Option) error {
 	symbolPrice := t.GetSymbolPrice(ctx, "")
 	bought := t.getBoughtInfo()
+	exit, _ := t.Strategy().(ExitStrategy)
 
 	for coin, info := range bought {
 		sp, ok := symbolPrice[coin]
+		if info.OrderListID != 0 && t.shouldReconcileOCO(info, sp, option.SellOption) && t.reconcileOCO(ctx, info) {
+			continue
+		}
 		if !ok {
 			continue
 		}
 
 		var (
 			price              = info.GetPrice()
-			takeProfitPrice    = price * (1 + info.TakeProfit)
+			takeProfitPrice    = price * (1 + info.TakeProfit/100)
 			stopLossPrice      = -1.0
 			forceStopLossPrice = -1.0
 			lastPrice          = sp.Price
@@ -112,37 +122,46 @@ func (t *Trade) checkingTPSL(ctx context.Context, option Option) error {
 		)
 
 		if info.StopLoss != nil {
-			stopLossPrice = price * (1 + *info.StopLoss)
+			stopLossPrice = price * (1 + *info.StopLoss/100)
 		}
 
 		if info.ForceStopLoss != nil {
-			forceStopLossPrice = price * (1 + *info.ForceStopLoss)
+			forceStopLossPrice = price * (1 + *info.ForceStopLoss/100)
 		}
 
-		// 止盈点.
-		if lastPrice >= takeProfitPrice {
-			// 持续止盈 止损
-			if option.SellOption.EnableTrailingTakeProfit {
-				info.TakeProfit += option.SellOption.TrailingTakeProfit
-				t.OnTrailingTakeProfit(info)
-				if info.StopLoss != nil {
-					sl := info.TakeProfit - option.SellOption.TrailingStopLoss + *info.StopLoss
-					info.StopLoss = &sl
+		if exit != nil && exit.ShouldExit(info, sp) {
+			shouldSell = true
+			sellReason = SellReasonStrategy
+		} else {
+			// 止盈点.
+			if lastPrice >= takeProfitPrice {
+				// 持续止盈 止损
+				if option.SellOption.EnableTrailingTakeProfit {
+					info.TakeProfit += option.SellOption.TrailingTakeProfit
+					if info.StopLoss != nil {
+						sl := *info.StopLoss + option.SellOption.TrailingStopLoss
+						info.StopLoss = &sl
+					}
+					t.updateBoughtInfo(info)
+					if info.OrderListID != 0 {
+						t.amendOCO(ctx, info)
+					}
+					t.OnTrailingTakeProfit(info)
+					continue
 				}
-				continue
+				shouldSell = true
+				sellReason = SellReasonForTakeProfit
 			}
-			shouldSell = true
-			sellReason = SellReasonForTakeProfit
-		}
 
-		if lastPrice <= forceStopLossPrice {
-			shouldSell = true
-			sellReason = SellReasonForForceStopLoss
-		} else {
-			if lastPrice < stopLossPrice {
-				if info.Time.Add(option.SellOption.StopLossDuration).After(time.Now()) {
-					shouldSell = true
-					sellReason = SellReasonForStopLoss
+			if lastPrice <= forceStopLossPrice {
+				shouldSell = true
+				sellReason = SellReasonForForceStopLoss
+			} else {
+				if lastPrice < stopLossPrice {
+					if !info.Time.Add(option.SellOption.StopLossDuration).After(t.now()) {
+						shouldSell = true
+						sellReason = SellReasonForStopLoss
+					}
 				}
 			}
 		}
@@ -150,6 +169,10 @@ func (t *Trade) checkingTPSL(ctx context.Context, option Option) error {
 		if !shouldSell {
 			continue
 		}
+		// the OCO sells at TP/SL on the exchange, force stop loss and strategy exits are still ours.
+		if info.OrderListID != 0 && (sellReason == SellReasonForTakeProfit || sellReason == SellReasonForStopLoss) {
+			continue
+		}
 
 		sellInfo := &SellBill{
 			Info:        info,
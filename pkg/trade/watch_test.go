@@ -0,0 +1,125 @@
+package trade
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// tpslCase holds a position bought at 1.00 with the TP/SL of option, prices are checked one by one.
+type tpslCase struct {
+	t     *testing.T
+	trade *Trade
+	sim   *SimExchange
+	now   time.Time
+}
+
+func newTPSLCase(t *testing.T, option SellOption) *tpslCase {
+	sim := newTestSim()
+	sim.SetPrice("DOGEUSDT", 1.00)
+	tr := NewTrade(
+		WithBuyOption(BuyOption{Interval: time.Second, MaxBuy: 1, MoneyPerOrder: 10, MainCoin: "USDT", WhiteList: []string{"DOGE"}}),
+		WithSellOption(option),
+	)
+	tr.SetExchange(sim)
+
+	c := &tpslCase{t: t, trade: tr, sim: sim, now: time.Now()}
+	tr.clock = func() time.Time { return c.now }
+	sl, fsl := -option.StopLoss, -option.ForceStopLoss
+	tr.boughtInfo["DOGEUSDT"] = &BoughtInfo{
+		Symbol:                   "DOGEUSDT",
+		OrderId:                  1,
+		Time:                     c.now,
+		StopLoss:                 &sl,
+		ForceStopLoss:            &fsl,
+		TakeProfit:               option.TakeProfit,
+		Volume:                   10,
+		ExecutedQuantity:         10,
+		CummulativeQuoteQuantity: 10,
+	}
+	return c
+}
+
+// check returns the sell the price triggers, nil if the position is kept.
+func (c *tpslCase) check(price float64) *SellBill {
+	c.t.Helper()
+	c.sim.SetPrice("DOGEUSDT", price)
+	if err := c.trade.checkingTPSL(context.Background(), c.trade.Option()); err != nil {
+		c.t.Fatal(err)
+	}
+	select {
+	case bill := <-c.trade.sellChan:
+		return bill
+	default:
+		return nil
+	}
+}
+
+// TakeProfit, StopLoss and ForceStopLoss are percents, 2 means +2%, not +200%.
+func TestTPSLArePercents(t *testing.T) {
+	c := newTPSLCase(t, testSellOption)
+
+	if bill := c.check(1.019); bill != nil {
+		t.Fatalf("expected no sell under TP, got %s", bill.Reason)
+	}
+	if bill := c.check(1.02); bill == nil || bill.Reason != SellReasonForTakeProfit {
+		t.Fatalf("expected take profit at +2%%, got %v", bill)
+	}
+	if bill := c.check(0.984); bill == nil || bill.Reason != SellReasonForStopLoss {
+		t.Fatalf("expected stop loss under -1.5%%, got %v", bill)
+	}
+	if bill := c.check(0.97); bill == nil || bill.Reason != SellReasonForForceStopLoss {
+		t.Fatalf("expected force stop loss at -3%%, got %v", bill)
+	}
+}
+
+// StopLossDuration is how long the price may stay under StopLoss before selling.
+func TestStopLossWaitsDuration(t *testing.T) {
+	option := testSellOption
+	option.StopLossDuration = time.Minute
+	c := newTPSLCase(t, option)
+
+	c.now = c.now.Add(30 * time.Second)
+	if bill := c.check(0.98); bill != nil {
+		t.Fatalf("expected the stop loss to wait, got %s", bill.Reason)
+	}
+	c.now = c.now.Add(30 * time.Second)
+	if bill := c.check(0.98); bill == nil || bill.Reason != SellReasonForStopLoss {
+		t.Fatalf("expected stop loss after StopLossDuration, got %v", bill)
+	}
+}
+
+// Trailing raises StopLoss by TrailingStopLoss each time TakeProfit is reached.
+func TestTrailingStopLoss(t *testing.T) {
+	option := testSellOption
+	option.EnableTrailingTakeProfit = true
+	option.TrailingTakeProfit = 2
+	option.TrailingStopLoss = 1
+	c := newTPSLCase(t, option)
+
+	if bill := c.check(1.02); bill != nil {
+		t.Fatalf("expected TP to trail, got %s", bill.Reason)
+	}
+	info := c.trade.getBoughtInfo()["DOGEUSDT"]
+	if info.TakeProfit != 4 || *info.StopLoss != -0.5 {
+		t.Fatalf("expected tp=4 sl=-0.5, got tp=%f sl=%f", info.TakeProfit, *info.StopLoss)
+	}
+	if bill := c.check(0.994); bill == nil || bill.Reason != SellReasonForStopLoss {
+		t.Fatalf("expected the raised stop loss, got %v", bill)
+	}
+}
+
+// WhiteList holds coins, they are matched with the quote appended.
+func TestInWhiteList(t *testing.T) {
+	option := BuyOption{MainCoin: "USDT", WhiteList: []string{"DOGE"}}
+	for symbol, want := range map[string]bool{
+		"DOGEUSDT": true,
+		"DOGE":     false,
+		"USDTDOGE": false,
+		"DOGEBTC":  false,
+	} {
+		if got := option.InWhiteList(symbol); got != want {
+			t.Errorf("InWhiteList(%s): expected %v, got %v", symbol, want, got)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Package webhook posts trade events as signed json to the configured urls.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"time"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body with the webhook secret
+	SignatureHeader = "X-Signature"
+
+	// EventHeader carries the event type
+	EventHeader = "X-Event"
+
+	defaultMaxRetries = 3
+)
+
+// Notifier posts the events of a trade to SystemOption.Webhooks
+type Notifier struct {
+	webhooks []trade.WebhookOption
+	client   *http.Client
+	logger   logrus.FieldLogger
+
+	// backoff the wait before the first retry, doubled every retry.
+	backoff time.Duration
+}
+
+// New returns a notifier of the trade's webhooks, nil if none configured.
+func New(t *trade.Trade) *Notifier {
+	webhooks := t.Option().SystemOption.Webhooks
+	if len(webhooks) == 0 {
+		return nil
+	}
+	return &Notifier{
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   t.Logger(),
+		backoff:  time.Second,
+	}
+}
+
+// OnEvent posts the event to every webhook accepting it
+func (n *Notifier) OnEvent(e trade.Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		n.logger.WithError(err).Error("failed to marshal webhook event")
+		return
+	}
+	for _, w := range n.webhooks {
+		if !w.Accept(e.Type) {
+			continue
+		}
+		go n.deliver(w, e.Type, body)
+	}
+}
+
+// deliver posts the body, retries on network errors, 429 and 5xx.
+func (n *Notifier) deliver(w trade.WebhookOption, event trade.EventType, body []byte) {
+	retries := w.MaxRetries
+	if retries == 0 {
+		retries = defaultMaxRetries
+	}
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(w, event, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= retries {
+			n.logger.WithError(err).Errorf("failed to post %s event to webhook %s", event, w.URL)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (n *Notifier) post(w trade.WebhookOption, event trade.EventType, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event))
+	if w.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.Secret, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// Sign returns the signature header of the body, receivers compare it with hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"encoding/json"
+	"github.com/clearcodecn/binance-bot/pkg/trade"
+	"github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestOnEvent(t *testing.T) {
+	var (
+		calls    int32
+		received = make(chan trade.Event, 1)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the first attempt fails and is retried.
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign("secret", body) {
+			t.Errorf("invalid signature %s", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get(EventHeader) != "sell" {
+			t.Errorf("unexpected event %s", r.Header.Get(EventHeader))
+		}
+		var e trade.Event
+		json.Unmarshal(body, &e)
+		received <- e
+	}))
+	defer server.Close()
+
+	n := &Notifier{
+		webhooks: []trade.WebhookOption{
+			{URL: server.URL, Secret: "secret", Events: []trade.EventType{trade.EventSell}},
+		},
+		client:  server.Client(),
+		logger:  logrus.New(),
+		backoff: 10 * time.Millisecond,
+	}
+	// filtered out.
+	n.OnEvent(trade.Event{Type: trade.EventBuy, Symbol: "ETHUSDT"})
+	n.OnEvent(trade.Event{Type: trade.EventSell, Symbol: "DOGEUSDT"})
+
+	select {
+	case e := <-received:
+		if e.Symbol != "DOGEUSDT" {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the event")
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestNoRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	n := &Notifier{client: server.Client(), logger: logrus.New(), backoff: time.Millisecond}
+	n.deliver(trade.WebhookOption{URL: server.URL, MaxRetries: 5}, trade.EventError, []byte(`{}`))
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
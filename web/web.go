@@ -0,0 +1,7 @@
+// Package web embeds the dashboard, so the binary is self-contained.
+package web
+
+import "embed"
+
+//go:embed *.html
+var FS embed.FS